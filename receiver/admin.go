@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/panzerdev/433mhz-receiver/receiver/admin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate protoc -I admin --go_out=plugins=grpc:admin admin/admin.proto
+
+// AdminServer serves the SensorAdmin service defined in admin/admin.proto
+// from the global state.
+type AdminServer struct {
+}
+
+func (s *AdminServer) ListUnmappedSensors(ctx context.Context, _ *empty.Empty) (*admin.UnmappedSensors, error) {
+	result := &admin.UnmappedSensors{}
+	for _, seen := range discovery.List() {
+		firstSeen, err := ptypes.TimestampProto(seen.FirstSeen)
+		if err != nil {
+			return nil, err
+		}
+		lastSeen, err := ptypes.TimestampProto(seen.LastSeen)
+		if err != nil {
+			return nil, err
+		}
+		sensor := &admin.UnmappedSensor{
+			Id:         seen.ID,
+			Key:        seen.Key,
+			Protocol:   seen.Protocol,
+			Channel:    int32(seen.Channel),
+			FirstSeen:  firstSeen,
+			LastSeen:   lastSeen,
+			Count:      int64(seen.Count),
+			LowBattery: seen.LowBattery,
+		}
+		for _, m := range seen.Measurements {
+			sensor.Measurements = append(sensor.Measurements, &admin.Measurement{
+				Name:  m.Name,
+				Value: m.Value,
+				Unit:  m.Unit,
+			})
+		}
+		result.Sensors = append(result.Sensors, sensor)
+	}
+	return result, nil
+}
+
+func (s *AdminServer) ListLocations(ctx context.Context, _ *empty.Empty) (*admin.SensorLocations, error) {
+	result := &admin.SensorLocations{}
+	for id, c := range locations.List() {
+		result.Locations = append(result.Locations, &admin.SensorLocation{
+			Id:       id,
+			Location: c.Location,
+		})
+	}
+	sort.Slice(result.Locations, func(i, j int) bool {
+		return result.Locations[i].Id < result.Locations[j].Id
+	})
+	return result, nil
+}
+
+func (s *AdminServer) SetLocation(ctx context.Context, req *admin.SensorLocation) (*empty.Empty, error) {
+	if err := locations.Set(req.Id, req.Location); err != nil {
+		return nil, locationsError(err, codes.InvalidArgument)
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *AdminServer) RemoveLocation(ctx context.Context, req *admin.SensorID) (*empty.Empty, error) {
+	if err := locations.Remove(req.Id); err != nil {
+		return nil, locationsError(err, codes.NotFound)
+	}
+	return &empty.Empty{}, nil
+}
+
+// locationsError converts a failed change of the mapping to a gRPC error:
+// an internal error if it couldn't be persisted, otherwise one with the code.
+func locationsError(err error, code codes.Code) error {
+	if _, ok := err.(*SaveError); ok {
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,569 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: admin.proto
+
+package admin
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import empty "github.com/golang/protobuf/ptypes/empty"
+import timestamp "github.com/golang/protobuf/ptypes/timestamp"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type Measurement struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value                float64  `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Unit                 string   `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Measurement) Reset()         { *m = Measurement{} }
+func (m *Measurement) String() string { return proto.CompactTextString(m) }
+func (*Measurement) ProtoMessage()    {}
+func (*Measurement) Descriptor() ([]byte, []int) {
+	return fileDescriptor_admin_93448adc0c1a0397, []int{0}
+}
+func (m *Measurement) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Measurement.Unmarshal(m, b)
+}
+func (m *Measurement) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Measurement.Marshal(b, m, deterministic)
+}
+func (dst *Measurement) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Measurement.Merge(dst, src)
+}
+func (m *Measurement) XXX_Size() int {
+	return xxx_messageInfo_Measurement.Size(m)
+}
+func (m *Measurement) XXX_DiscardUnknown() {
+	xxx_messageInfo_Measurement.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Measurement proto.InternalMessageInfo
+
+func (m *Measurement) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Measurement) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *Measurement) GetUnit() string {
+	if m != nil {
+		return m.Unit
+	}
+	return ""
+}
+
+type UnmappedSensor struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// what the sensor must be mapped to a location by
+	Key                  string               `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Protocol             string               `protobuf:"bytes,3,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Channel              int32                `protobuf:"varint,4,opt,name=channel,proto3" json:"channel,omitempty"`
+	FirstSeen            *timestamp.Timestamp `protobuf:"bytes,5,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
+	LastSeen             *timestamp.Timestamp `protobuf:"bytes,6,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Count                int64                `protobuf:"varint,7,opt,name=count,proto3" json:"count,omitempty"`
+	LowBattery           bool                 `protobuf:"varint,8,opt,name=low_battery,json=lowBattery,proto3" json:"low_battery,omitempty"`
+	Measurements         []*Measurement       `protobuf:"bytes,9,rep,name=measurements,proto3" json:"measurements,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *UnmappedSensor) Reset()         { *m = UnmappedSensor{} }
+func (m *UnmappedSensor) String() string { return proto.CompactTextString(m) }
+func (*UnmappedSensor) ProtoMessage()    {}
+func (*UnmappedSensor) Descriptor() ([]byte, []int) {
+	return fileDescriptor_admin_93448adc0c1a0397, []int{1}
+}
+func (m *UnmappedSensor) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnmappedSensor.Unmarshal(m, b)
+}
+func (m *UnmappedSensor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnmappedSensor.Marshal(b, m, deterministic)
+}
+func (dst *UnmappedSensor) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnmappedSensor.Merge(dst, src)
+}
+func (m *UnmappedSensor) XXX_Size() int {
+	return xxx_messageInfo_UnmappedSensor.Size(m)
+}
+func (m *UnmappedSensor) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnmappedSensor.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnmappedSensor proto.InternalMessageInfo
+
+func (m *UnmappedSensor) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *UnmappedSensor) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *UnmappedSensor) GetProtocol() string {
+	if m != nil {
+		return m.Protocol
+	}
+	return ""
+}
+
+func (m *UnmappedSensor) GetChannel() int32 {
+	if m != nil {
+		return m.Channel
+	}
+	return 0
+}
+
+func (m *UnmappedSensor) GetFirstSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.FirstSeen
+	}
+	return nil
+}
+
+func (m *UnmappedSensor) GetLastSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastSeen
+	}
+	return nil
+}
+
+func (m *UnmappedSensor) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *UnmappedSensor) GetLowBattery() bool {
+	if m != nil {
+		return m.LowBattery
+	}
+	return false
+}
+
+func (m *UnmappedSensor) GetMeasurements() []*Measurement {
+	if m != nil {
+		return m.Measurements
+	}
+	return nil
+}
+
+type UnmappedSensors struct {
+	Sensors              []*UnmappedSensor `protobuf:"bytes,1,rep,name=sensors,proto3" json:"sensors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *UnmappedSensors) Reset()         { *m = UnmappedSensors{} }
+func (m *UnmappedSensors) String() string { return proto.CompactTextString(m) }
+func (*UnmappedSensors) ProtoMessage()    {}
+func (*UnmappedSensors) Descriptor() ([]byte, []int) {
+	return fileDescriptor_admin_93448adc0c1a0397, []int{2}
+}
+func (m *UnmappedSensors) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnmappedSensors.Unmarshal(m, b)
+}
+func (m *UnmappedSensors) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnmappedSensors.Marshal(b, m, deterministic)
+}
+func (dst *UnmappedSensors) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnmappedSensors.Merge(dst, src)
+}
+func (m *UnmappedSensors) XXX_Size() int {
+	return xxx_messageInfo_UnmappedSensors.Size(m)
+}
+func (m *UnmappedSensors) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnmappedSensors.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnmappedSensors proto.InternalMessageInfo
+
+func (m *UnmappedSensors) GetSensors() []*UnmappedSensor {
+	if m != nil {
+		return m.Sensors
+	}
+	return nil
+}
+
+type SensorID struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SensorID) Reset()         { *m = SensorID{} }
+func (m *SensorID) String() string { return proto.CompactTextString(m) }
+func (*SensorID) ProtoMessage()    {}
+func (*SensorID) Descriptor() ([]byte, []int) {
+	return fileDescriptor_admin_93448adc0c1a0397, []int{3}
+}
+func (m *SensorID) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SensorID.Unmarshal(m, b)
+}
+func (m *SensorID) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SensorID.Marshal(b, m, deterministic)
+}
+func (dst *SensorID) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SensorID.Merge(dst, src)
+}
+func (m *SensorID) XXX_Size() int {
+	return xxx_messageInfo_SensorID.Size(m)
+}
+func (m *SensorID) XXX_DiscardUnknown() {
+	xxx_messageInfo_SensorID.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SensorID proto.InternalMessageInfo
+
+func (m *SensorID) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type SensorLocation struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Location             string   `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SensorLocation) Reset()         { *m = SensorLocation{} }
+func (m *SensorLocation) String() string { return proto.CompactTextString(m) }
+func (*SensorLocation) ProtoMessage()    {}
+func (*SensorLocation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_admin_93448adc0c1a0397, []int{4}
+}
+func (m *SensorLocation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SensorLocation.Unmarshal(m, b)
+}
+func (m *SensorLocation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SensorLocation.Marshal(b, m, deterministic)
+}
+func (dst *SensorLocation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SensorLocation.Merge(dst, src)
+}
+func (m *SensorLocation) XXX_Size() int {
+	return xxx_messageInfo_SensorLocation.Size(m)
+}
+func (m *SensorLocation) XXX_DiscardUnknown() {
+	xxx_messageInfo_SensorLocation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SensorLocation proto.InternalMessageInfo
+
+func (m *SensorLocation) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *SensorLocation) GetLocation() string {
+	if m != nil {
+		return m.Location
+	}
+	return ""
+}
+
+type SensorLocations struct {
+	Locations            []*SensorLocation `protobuf:"bytes,1,rep,name=locations,proto3" json:"locations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SensorLocations) Reset()         { *m = SensorLocations{} }
+func (m *SensorLocations) String() string { return proto.CompactTextString(m) }
+func (*SensorLocations) ProtoMessage()    {}
+func (*SensorLocations) Descriptor() ([]byte, []int) {
+	return fileDescriptor_admin_93448adc0c1a0397, []int{5}
+}
+func (m *SensorLocations) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SensorLocations.Unmarshal(m, b)
+}
+func (m *SensorLocations) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SensorLocations.Marshal(b, m, deterministic)
+}
+func (dst *SensorLocations) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SensorLocations.Merge(dst, src)
+}
+func (m *SensorLocations) XXX_Size() int {
+	return xxx_messageInfo_SensorLocations.Size(m)
+}
+func (m *SensorLocations) XXX_DiscardUnknown() {
+	xxx_messageInfo_SensorLocations.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SensorLocations proto.InternalMessageInfo
+
+func (m *SensorLocations) GetLocations() []*SensorLocation {
+	if m != nil {
+		return m.Locations
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Measurement)(nil), "receiver.Measurement")
+	proto.RegisterType((*UnmappedSensor)(nil), "receiver.UnmappedSensor")
+	proto.RegisterType((*UnmappedSensors)(nil), "receiver.UnmappedSensors")
+	proto.RegisterType((*SensorID)(nil), "receiver.SensorID")
+	proto.RegisterType((*SensorLocation)(nil), "receiver.SensorLocation")
+	proto.RegisterType((*SensorLocations)(nil), "receiver.SensorLocations")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// SensorAdminClient is the client API for SensorAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type SensorAdminClient interface {
+	// ListUnmappedSensors lists the sensors that are received but not
+	// mapped to a location, most recently seen first.
+	ListUnmappedSensors(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*UnmappedSensors, error)
+	// ListLocations lists the locations of all mapped sensors.
+	ListLocations(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*SensorLocations, error)
+	// SetLocation adds a sensor or changes its location.
+	SetLocation(ctx context.Context, in *SensorLocation, opts ...grpc.CallOption) (*empty.Empty, error)
+	// RemoveLocation removes a sensor from the mapping.
+	RemoveLocation(ctx context.Context, in *SensorID, opts ...grpc.CallOption) (*empty.Empty, error)
+}
+
+type sensorAdminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSensorAdminClient(cc *grpc.ClientConn) SensorAdminClient {
+	return &sensorAdminClient{cc}
+}
+
+func (c *sensorAdminClient) ListUnmappedSensors(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*UnmappedSensors, error) {
+	out := new(UnmappedSensors)
+	err := c.cc.Invoke(ctx, "/receiver.SensorAdmin/ListUnmappedSensors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorAdminClient) ListLocations(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*SensorLocations, error) {
+	out := new(SensorLocations)
+	err := c.cc.Invoke(ctx, "/receiver.SensorAdmin/ListLocations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorAdminClient) SetLocation(ctx context.Context, in *SensorLocation, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/receiver.SensorAdmin/SetLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorAdminClient) RemoveLocation(ctx context.Context, in *SensorID, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/receiver.SensorAdmin/RemoveLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SensorAdminServer is the server API for SensorAdmin service.
+type SensorAdminServer interface {
+	// ListUnmappedSensors lists the sensors that are received but not
+	// mapped to a location, most recently seen first.
+	ListUnmappedSensors(context.Context, *empty.Empty) (*UnmappedSensors, error)
+	// ListLocations lists the locations of all mapped sensors.
+	ListLocations(context.Context, *empty.Empty) (*SensorLocations, error)
+	// SetLocation adds a sensor or changes its location.
+	SetLocation(context.Context, *SensorLocation) (*empty.Empty, error)
+	// RemoveLocation removes a sensor from the mapping.
+	RemoveLocation(context.Context, *SensorID) (*empty.Empty, error)
+}
+
+func RegisterSensorAdminServer(s *grpc.Server, srv SensorAdminServer) {
+	s.RegisterService(&_SensorAdmin_serviceDesc, srv)
+}
+
+func _SensorAdmin_ListUnmappedSensors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorAdminServer).ListUnmappedSensors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/receiver.SensorAdmin/ListUnmappedSensors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorAdminServer).ListUnmappedSensors(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorAdmin_ListLocations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorAdminServer).ListLocations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/receiver.SensorAdmin/ListLocations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorAdminServer).ListLocations(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorAdmin_SetLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorLocation)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorAdminServer).SetLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/receiver.SensorAdmin/SetLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorAdminServer).SetLocation(ctx, req.(*SensorLocation))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorAdmin_RemoveLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorAdminServer).RemoveLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/receiver.SensorAdmin/RemoveLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorAdminServer).RemoveLocation(ctx, req.(*SensorID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _SensorAdmin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "receiver.SensorAdmin",
+	HandlerType: (*SensorAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListUnmappedSensors",
+			Handler:    _SensorAdmin_ListUnmappedSensors_Handler,
+		},
+		{
+			MethodName: "ListLocations",
+			Handler:    _SensorAdmin_ListLocations_Handler,
+		},
+		{
+			MethodName: "SetLocation",
+			Handler:    _SensorAdmin_SetLocation_Handler,
+		},
+		{
+			MethodName: "RemoveLocation",
+			Handler:    _SensorAdmin_RemoveLocation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
+
+func init() { proto.RegisterFile("admin.proto", fileDescriptor_admin_93448adc0c1a0397) }
+
+var fileDescriptor_admin_93448adc0c1a0397 = []byte{
+	// 477 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xd1, 0x6e, 0x94, 0x40,
+	0x14, 0x0d, 0x6c, 0xe9, 0xc2, 0x45, 0xb7, 0x66, 0xac, 0x66, 0xc4, 0x87, 0x12, 0x9e, 0x78, 0xa2,
+	0xc9, 0x9a, 0x68, 0x9a, 0x34, 0x31, 0x36, 0x6d, 0xe2, 0xc6, 0xfa, 0x32, 0xd5, 0x17, 0x5f, 0x9a,
+	0x59, 0xf6, 0xb6, 0x12, 0x61, 0x86, 0x30, 0xc3, 0x36, 0xfb, 0x2b, 0x7e, 0x94, 0xdf, 0x64, 0x60,
+	0x60, 0x57, 0xa8, 0x1b, 0x7d, 0xbb, 0x77, 0xce, 0xb9, 0x27, 0xf7, 0xdc, 0x03, 0xe0, 0xf3, 0x55,
+	0x91, 0x89, 0xa4, 0xac, 0xa4, 0x96, 0xc4, 0xad, 0x30, 0xc5, 0x6c, 0x8d, 0x55, 0xf0, 0xfa, 0x5e,
+	0xca, 0xfb, 0x1c, 0x4f, 0xdb, 0xf7, 0x65, 0x7d, 0x77, 0x8a, 0x45, 0xa9, 0x37, 0x86, 0x16, 0x9c,
+	0x8c, 0x41, 0x9d, 0x15, 0xa8, 0x34, 0x2f, 0x4a, 0x43, 0x88, 0x3e, 0x81, 0xff, 0x19, 0xb9, 0xaa,
+	0x2b, 0x2c, 0x50, 0x68, 0x42, 0xe0, 0x40, 0xf0, 0x02, 0xa9, 0x15, 0x5a, 0xb1, 0xc7, 0xda, 0x9a,
+	0x1c, 0x83, 0xb3, 0xe6, 0x79, 0x8d, 0xd4, 0x0e, 0xad, 0xd8, 0x62, 0xa6, 0x69, 0x98, 0xb5, 0xc8,
+	0x34, 0x9d, 0x18, 0x66, 0x53, 0x47, 0xbf, 0x6c, 0x98, 0x7d, 0x15, 0x05, 0x2f, 0x4b, 0x5c, 0xdd,
+	0xa0, 0x50, 0xb2, 0x22, 0x33, 0xb0, 0xb3, 0x55, 0x27, 0x67, 0x67, 0x2b, 0xf2, 0x0c, 0x26, 0x3f,
+	0x70, 0xd3, 0x4a, 0x79, 0xac, 0x29, 0x49, 0x00, 0x6e, 0xbb, 0x4a, 0x2a, 0xf3, 0x4e, 0x6c, 0xdb,
+	0x13, 0x0a, 0xd3, 0xf4, 0x3b, 0x17, 0x02, 0x73, 0x7a, 0x10, 0x5a, 0xb1, 0xc3, 0xfa, 0x96, 0x9c,
+	0x01, 0xdc, 0x65, 0x95, 0xd2, 0xb7, 0x0a, 0x51, 0x50, 0x27, 0xb4, 0x62, 0x7f, 0x1e, 0x24, 0xc6,
+	0x6d, 0xd2, 0xbb, 0x4d, 0xbe, 0xf4, 0x6e, 0x99, 0xd7, 0xb2, 0x6f, 0x10, 0x05, 0x79, 0x07, 0x5e,
+	0xce, 0xfb, 0xc9, 0xc3, 0x7f, 0x4e, 0xba, 0x39, 0xef, 0x06, 0x8f, 0xc1, 0x49, 0x65, 0x2d, 0x34,
+	0x9d, 0x86, 0x56, 0x3c, 0x61, 0xa6, 0x21, 0x27, 0xe0, 0xe7, 0xf2, 0xe1, 0x76, 0xc9, 0xb5, 0xc6,
+	0x6a, 0x43, 0xdd, 0xd0, 0x8a, 0x5d, 0x06, 0xb9, 0x7c, 0xb8, 0x30, 0x2f, 0xe4, 0x0c, 0x9e, 0x14,
+	0xbb, 0x13, 0x2b, 0xea, 0x85, 0x93, 0xd8, 0x9f, 0xbf, 0x48, 0xfa, 0x04, 0x93, 0x3f, 0x02, 0x60,
+	0x03, 0x6a, 0x74, 0x05, 0x47, 0xc3, 0x7b, 0x2a, 0x32, 0x87, 0xa9, 0x32, 0x25, 0xb5, 0x5a, 0x21,
+	0xba, 0x13, 0x1a, 0x72, 0x59, 0x4f, 0x8c, 0x02, 0x70, 0xcd, 0xd3, 0xe2, 0x72, 0x1c, 0x48, 0x74,
+	0x0e, 0x33, 0x83, 0x5d, 0xcb, 0x94, 0xeb, 0x4c, 0x8a, 0x47, 0x91, 0x05, 0xe0, 0xe6, 0x1d, 0xd6,
+	0xe5, 0xb6, 0xed, 0xa3, 0x05, 0x1c, 0x0d, 0xa7, 0x15, 0x79, 0x0b, 0x5e, 0x0f, 0xff, 0x65, 0xc5,
+	0x21, 0x9b, 0xed, 0xa8, 0xf3, 0x9f, 0x36, 0xf8, 0x06, 0xfd, 0xd0, 0x7c, 0xe7, 0xe4, 0x23, 0x3c,
+	0xbf, 0xce, 0x94, 0x1e, 0xfb, 0x7f, 0xf9, 0x28, 0xaa, 0xab, 0xe6, 0x7b, 0x0f, 0x5e, 0xed, 0x3b,
+	0x83, 0x22, 0x17, 0xf0, 0xb4, 0x51, 0xda, 0xad, 0xf8, 0x1f, 0x1a, 0x63, 0x57, 0xef, 0x9b, 0xe5,
+	0xb6, 0x12, 0x64, 0xaf, 0xa3, 0x60, 0x8f, 0x36, 0x39, 0x87, 0x19, 0xc3, 0x42, 0xae, 0x71, 0xab,
+	0x41, 0xc6, 0x1a, 0x8b, 0xcb, 0x7d, 0xd3, 0x17, 0xd3, 0x6f, 0x4e, 0xfb, 0xf7, 0x2f, 0x0f, 0x5b,
+	0xe0, 0xcd, 0xef, 0x01, 0x00, 0x50, 0xff, 0x20, 0x2d, 0x0d, 0x04, 0x00, 0x00,
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/panzerdev/433mhz-receiver/receiver/admin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dialAdmin serves the SensorAdmin service on a local port and connects to it.
+func dialAdmin(t *testing.T) (*grpc.ClientConn, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	admin.RegisterSensorAdminServer(server, &AdminServer{})
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	assert.NoError(t, err)
+	return conn, func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func TestAdminServer_ListUnmappedSensors(t *testing.T) {
+	defer func(d *Discovery) { discovery = d }(discovery)
+	discovery = NewDiscovery()
+	seen := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	discovery.Seen(&Reading{
+		Protocol:     "GT-WT-01",
+		SensorID:     "2454",
+		Key:          "2454",
+		Channel:      2,
+		Time:         seen,
+		Measurements: []Measurement{{FieldTemperature, 21.5, UnitCelsius}},
+	})
+
+	conn, stop := dialAdmin(t)
+	defer stop()
+	var sensors admin.UnmappedSensors
+	err := conn.Invoke(context.Background(), "/receiver.SensorAdmin/ListUnmappedSensors", &empty.Empty{}, &sensors)
+	assert.NoError(t, err)
+	if assert.Len(t, sensors.Sensors, 1) {
+		s := sensors.Sensors[0]
+		assert.Equal(t, "2454", s.Id)
+		assert.Equal(t, "GT-WT-01", s.Protocol)
+		assert.Equal(t, int32(2), s.Channel)
+		assert.Equal(t, seen.Unix(), s.LastSeen.Seconds)
+		assert.Equal(t, int64(1), s.Count)
+		assert.Equal(t, []*admin.Measurement{{Name: FieldTemperature, Value: 21.5, Unit: UnitCelsius}}, s.Measurements)
+	}
+}
+
+func TestAdminServer_locations(t *testing.T) {
+	defer func(l *Locations) { locations = l }(locations)
+	var err error
+	locations, err = NewLocations("", map[string]string{"200": "Grube"})
+	assert.NoError(t, err)
+
+	conn, stop := dialAdmin(t)
+	defer stop()
+	ctx := context.Background()
+	assert.NoError(t, conn.Invoke(ctx, "/receiver.SensorAdmin/SetLocation", &admin.SensorLocation{Id: "2454", Location: "kitchen"}, &empty.Empty{}))
+	assert.NoError(t, conn.Invoke(ctx, "/receiver.SensorAdmin/RemoveLocation", &admin.SensorID{Id: "200"}, &empty.Empty{}))
+
+	err = conn.Invoke(ctx, "/receiver.SensorAdmin/RemoveLocation", &admin.SensorID{Id: "200"}, &empty.Empty{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	err = conn.Invoke(ctx, "/receiver.SensorAdmin/SetLocation", &admin.SensorLocation{Id: "7"}, &empty.Empty{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	var list admin.SensorLocations
+	assert.NoError(t, conn.Invoke(ctx, "/receiver.SensorAdmin/ListLocations", &empty.Empty{}, &list))
+	assert.Equal(t, []*admin.SensorLocation{{Id: "2454", Location: "kitchen"}}, list.Locations)
+}
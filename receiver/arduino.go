@@ -2,50 +2,256 @@ package main
 
 import (
 	"bufio"
-	"go.bug.st/serial.v1"
+	"fmt"
 	"io"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
 	// ReceivePrefix is the prefix of raw signals read from the device file of the Arduino.
 	ReceivePrefix = "RF receive "
+	// ReadyBanner is printed by the Arduino when it has started.
+	ReadyBanner = "ready"
+)
+
+// Connection states of a device.
+const (
+	StateDisconnected = "disconnected"
+	StateConnected    = "connected" // the port is open, but the Arduino hasn't reported to be ready
+	StateReady        = "ready"
 )
 
-// Device represents the device file of an Arduino
-// connected to the USB port.
+// Delays between attempts to reopen a device, doubling after each failed attempt.
+var (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Device represents the device file of an Arduino connected
+// to the USB port, or another source of its raw lines. It is
+// reopened whenever reading from it fails, e.g. because it was
+// unplugged.
 type Device struct {
-	io.ReadCloser
-	readChan chan string
+	name        string
+	source      Source
+	readTimeout time.Duration
+	minDelay    time.Duration // between attempts to reopen the source
+	readChan    chan string
+	done        chan struct{}
+
+	mu         sync.Mutex
+	port       io.ReadCloser
+	state      string
+	since      time.Time
+	reconnects int
 }
 
-// OpenDevice opens the named device file for reading.
-func OpenDevice(name string) (*Device, error) {
-	mode := &serial.Mode{
-		BaudRate: 115200,
-	}
-	file, err := serial.Open(name, mode)
-	if err != nil {
-		log.Fatal("Error open", err)
+// DeviceStatus is the state of the connection to a device.
+type DeviceStatus struct {
+	Device     string    `json:"device"`
+	State      string    `json:"state"`
+	Since      time.Time `json:"since"`
+	Reconnects int       `json:"reconnects"`
+}
+
+// OpenDevice opens the serial port the Arduino is connected to for reading.
+func OpenDevice(c SerialConfig) (*Device, error) {
+	if err := c.validate(); err != nil {
+		return nil, errors.Wrapf(err, "Invalid configuration of serial port '%v'", c)
 	}
+	return OpenSource(serialSource(c), c.ReadTimeout), nil
+}
 
+// OpenSource opens the source for reading in the background, so a source
+// that isn't available yet doesn't block. It is reopened when opening or
+// reading fails, or nothing was read within the timeout, unless it is 0.
+func OpenSource(s Source, timeout time.Duration) *Device {
 	d := &Device{
-		ReadCloser: file,
+		name:        s.String(),
+		source:      s,
+		readTimeout: timeout,
+		minDelay:    minReconnectDelay,
+		readChan:    make(chan string),
+		done:        make(chan struct{}),
 	}
+	d.setState(StateDisconnected)
+	go d.run()
+	return d
+}
 
-	d.readChan = d.subscribe()
-	return d, nil
+// Close stops reading from the device and closes it.
+func (d *Device) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	select {
+	case <-d.done:
+		return nil
+	default:
+	}
+	close(d.done)
+	if c, ok := d.source.(io.Closer); ok {
+		c.Close()
+	}
+	if d.port != nil {
+		return d.port.Close()
+	}
+	return nil
+}
+
+// Status returns the state of the connection to the device.
+func (d *Device) Status() DeviceStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return DeviceStatus{
+		Device:     d.name,
+		State:      d.state,
+		Since:      d.since,
+		Reconnects: d.reconnects,
+	}
 }
 
-func (d *Device) subscribe() chan string {
-	res := make(chan string)
-	go func() {
-		scanner := bufio.NewScanner(d)
-		for scanner.Scan() {
-			line := scanner.Text()
-			log.Println("Line Scanned:", line)
-			res <- line
+// run opens the device and reads lines from it until it is closed,
+// reopening it whenever reading fails.
+func (d *Device) run() {
+	defer close(d.readChan)
+	port, ok := d.open(0)
+	for ok {
+		d.connected(port)
+		err := d.read(port)
+		port.Close()
+		if d.closed() {
+			return
+		}
+		log.Printf("Lost connection to '%s': %v\n", d.name, err)
+		d.setState(StateDisconnected)
+
+		if port, ok = d.open(d.minDelay); ok {
+			d.mu.Lock()
+			d.reconnects++
+			d.mu.Unlock()
+			deviceReconnects.With(prometheus.Labels{DeviceName: d.name}).Inc()
+			log.Printf("Reconnected to '%s'\n", d.name)
+		}
+	}
+}
+
+// read passes the lines read from the port on until reading fails.
+// If nothing is read within the read timeout, the port is closed.
+func (d *Device) read(port io.ReadCloser) error {
+	var timedOut int32
+	var r io.Reader = port
+	if d.readTimeout > 0 {
+		timer := time.AfterFunc(d.readTimeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			port.Close()
+		})
+		defer timer.Stop()
+		r = &activityReader{Reader: port, timer: timer, timeout: d.readTimeout}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == ReadyBanner {
+			log.Printf("Arduino at '%s' is ready\n", d.name)
+			d.setState(StateReady)
+			continue
+		}
+		select {
+		case d.readChan <- line:
+		case <-d.done:
+			return nil
 		}
-	}()
-	return res
+	}
+	if atomic.LoadInt32(&timedOut) == 1 {
+		return fmt.Errorf("nothing read within %v", d.readTimeout)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// activityReader restarts the timer whenever something was read.
+type activityReader struct {
+	io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *activityReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+// open tries to open the device after the delay, and then with increasing
+// delays until it succeeds, the device is closed or the source has no more input.
+func (d *Device) open(delay time.Duration) (io.ReadCloser, bool) {
+	for {
+		select {
+		case <-time.After(delay):
+		case <-d.done:
+			return nil, false
+		}
+		port, err := d.source.Open()
+		if err == nil {
+			return port, true
+		}
+		if err == io.EOF {
+			log.Printf("No more input from '%s'\n", d.name)
+			return nil, false
+		}
+		if d.closed() {
+			return nil, false
+		}
+		if delay *= 2; delay < d.minDelay {
+			delay = d.minDelay
+		} else if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+		log.Printf("Failed to open '%s', retrying in %v: %v\n", d.name, delay, err)
+	}
+}
+
+// connected makes the port the one being read from. If the device was
+// closed in the meantime, the port is closed so reading from it ends.
+func (d *Device) connected(port io.ReadCloser) {
+	d.mu.Lock()
+	d.port = port
+	if d.closed() {
+		port.Close()
+	}
+	d.mu.Unlock()
+	d.setState(StateConnected)
+}
+
+func (d *Device) closed() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Device) setState(state string) {
+	d.mu.Lock()
+	d.state = state
+	d.since = time.Now()
+	d.mu.Unlock()
+
+	connected := 0.0
+	if state != StateDisconnected {
+		connected = 1
+	}
+	deviceConnected.With(prometheus.Labels{DeviceName: d.name}).Set(connected)
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pipeSource opens pipes, passing their writing end to the test.
+type pipeSource chan *io.PipeWriter
+
+func (s pipeSource) Open() (io.ReadCloser, error) {
+	r, w := io.Pipe()
+	s <- w
+	return r, nil
+}
+
+func (s pipeSource) String() string {
+	return "test"
+}
+
+func TestDevice_reconnect(t *testing.T) {
+	minReconnectDelay = time.Millisecond
+	defer func() { minReconnectDelay = time.Second }()
+
+	ports := make(chan *io.PipeWriter, 2)
+	d := OpenSource(pipeSource(ports), 0)
+	w := <-ports
+	io.WriteString(w, "RF receive 0\r\n")
+	assert.Equal(t, "RF receive 0", <-d.readChan)
+	assert.Equal(t, StateConnected, d.Status().State)
+
+	io.WriteString(w, "ready\r\nRF receive 1 2 3\r\n")
+	assert.Equal(t, "RF receive 1 2 3", <-d.readChan)
+	assert.Equal(t, StateReady, d.Status().State)
+
+	w.CloseWithError(io.ErrUnexpectedEOF)
+	w = <-ports
+	io.WriteString(w, "RF receive 4 5 6\r\n")
+	assert.Equal(t, "RF receive 4 5 6", <-d.readChan)
+	assert.Equal(t, 1, d.Status().Reconnects)
+	assert.Equal(t, StateConnected, d.Status().State, "no banner after reconnecting")
+
+	assert.NoError(t, d.Close())
+	_, ok := <-d.readChan
+	assert.False(t, ok)
+}
+
+func TestDevice_readTimeout(t *testing.T) {
+	minReconnectDelay = time.Millisecond
+	defer func() { minReconnectDelay = time.Second }()
+
+	opened := make(chan *io.PipeWriter, 2)
+	d := OpenSource(pipeSource(opened), 20*time.Millisecond)
+	defer d.Close()
+	<-opened
+
+	select {
+	case <-opened:
+	case <-time.After(time.Second):
+		t.Fatal("silent device wasn't reopened")
+	}
+}
+
+// funcSource opens ports by calling the function.
+type funcSource func() (io.ReadCloser, error)
+
+func (s funcSource) Open() (io.ReadCloser, error) {
+	return s()
+}
+
+func (s funcSource) String() string {
+	return "test"
+}
+
+func TestOpenSource_unavailable(t *testing.T) {
+	minReconnectDelay = time.Millisecond
+	defer func() { minReconnectDelay = time.Second }()
+
+	attempts := make(chan int, 10)
+	var n int
+	d := OpenSource(funcSource(func() (io.ReadCloser, error) {
+		n++
+		attempts <- n
+		if n < 3 {
+			return nil, errors.New("not plugged in")
+		}
+		return ioutil.NopCloser(strings.NewReader("RF receive 1 2\n")), nil
+	}), 0)
+	defer d.Close()
+
+	assert.Equal(t, "RF receive 1 2", <-d.readChan, "opened in the background")
+	assert.Equal(t, 3, len(attempts))
+	assert.Equal(t, 0, d.Status().Reconnects)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// AlertLowBattery is the name of alerts about sensors with a low battery.
+const AlertLowBattery = "low-battery"
+
+const (
+	// lowBatteryReadings is the number of consecutive readings with a low
+	// battery after which a sensor is alerted about.
+	lowBatteryReadings = 3
+	// okBatteryReadings is the number of consecutive readings without a low
+	// battery after which an alerted sensor can be alerted about again.
+	okBatteryReadings = 10
+)
+
+// batteryState counts the consecutive readings of a sensor
+// with and without a low battery.
+type batteryState struct {
+	low, ok int
+	alerted bool
+}
+
+// BatteryWatcher alerts once when a sensor with a location starts reporting
+// a low battery. The hysteresis of consecutive readings avoids alerting
+// repeatedly about a battery that flickers around the threshold, e.g. on
+// cold nights. It is safe for concurrent use.
+type BatteryWatcher struct {
+	mu      sync.Mutex
+	bus     *Bus
+	sensors map[string]*batteryState
+}
+
+// NewBatteryWatcher creates a watcher that publishes its alerts on the bus.
+func NewBatteryWatcher(bus *Bus) *BatteryWatcher {
+	return &BatteryWatcher{
+		bus:     bus,
+		sensors: map[string]*batteryState{},
+	}
+}
+
+// Handle checks the battery state of reading events.
+func (w *BatteryWatcher) Handle(e Event) {
+	if e.Kind != ReadingEvent || e.Reading.Location == "" {
+		return
+	}
+	r := e.Reading
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := r.Key + "\x00" + r.Location
+	s, ok := w.sensors[id]
+	if !ok {
+		s = &batteryState{}
+		w.sensors[id] = s
+	}
+
+	if !r.LowBattery {
+		s.low = 0
+		s.ok++
+		if s.alerted && s.ok >= okBatteryReadings {
+			log.Printf("Battery of sensor %s in %s is fine again\n", r.Key, r.Location)
+			s.alerted = false
+		}
+		return
+	}
+
+	s.ok = 0
+	s.low++
+	if !s.alerted && s.low >= lowBatteryReadings {
+		s.alerted = true
+		w.bus.Publish(Event{Kind: AlertEvent, Alert: &Alert{
+			Name:     AlertLowBattery,
+			Key:      r.Key,
+			Location: r.Location,
+			Message:  fmt.Sprintf("Battery of sensor %s in %s is low", r.Key, r.Location),
+		}})
+	}
+}
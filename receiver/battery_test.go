@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatteryWatcher_hysteresis(t *testing.T) {
+	bus := NewBus()
+	alerts := bus.Subscribe("test", 10)
+	w := NewBatteryWatcher(bus)
+	report := func(n int, low bool) {
+		for i := 0; i < n; i++ {
+			w.Handle(Event{Kind: ReadingEvent, Reading: &Reading{Key: "2454", Location: "kitchen", LowBattery: low}})
+		}
+	}
+
+	report(lowBatteryReadings-1, true)
+	report(1, false)
+	report(lowBatteryReadings-1, true)
+	assert.Len(t, alerts, 0, "not enough consecutive low readings")
+
+	report(1, true)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, AlertLowBattery, (<-alerts).Alert.Name)
+
+	report(okBatteryReadings-1, false)
+	report(lowBatteryReadings, true)
+	assert.Len(t, alerts, 0, "battery wasn't fine long enough")
+
+	report(okBatteryReadings, false)
+	report(lowBatteryReadings, true)
+	assert.Len(t, alerts, 1)
+}
+
+func TestExportReading_battery(t *testing.T) {
+	defer DeleteSensorMetrics("2454", "kitchen")
+	defer DeleteSensorMetrics("200", "Grube")
+	ExportReading(&Reading{Key: "2454", Location: "kitchen", HasBattery: true, LowBattery: true,
+		Measurements: []Measurement{{FieldTemperature, 21, UnitCelsius}}})
+	ExportReading(&Reading{Key: "200", Location: "Grube",
+		Measurements: []Measurement{{FieldDistance, 80, UnitCentimeter}}})
+
+	series := make(chan prometheus.Metric, 10)
+	lowBattery.Collect(series)
+	close(series)
+	var sensors []string
+	for s := range series {
+		var m dto.Metric
+		assert.NoError(t, s.Write(&m))
+		for _, l := range m.Label {
+			if l.GetName() == SensorID {
+				sensors = append(sensors, l.GetValue())
+			}
+		}
+	}
+	assert.Equal(t, []string{"2454"}, sensors, "only sensors reporting their battery state")
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EventKind is the kind of an event published on the bus.
+type EventKind uint8
+
+const (
+	// ReadingEvent carries the reading of a sensor.
+	ReadingEvent EventKind = iota
+	// ButtonEvent is published when a button, e.g. a doorbell, was pressed.
+	ButtonEvent
+	// AlertEvent carries an alert about a sensor.
+	AlertEvent
+)
+
+// Event is published on the bus by the decoder and consumed by sinks.
+type Event struct {
+	Kind    EventKind
+	Time    time.Time
+	Reading *Reading
+	Alert   *Alert
+}
+
+// Alert is raised when something about a sensor needs attention,
+// and resolved once it doesn't anymore.
+type Alert struct {
+	Name     string // kind of the alert, e.g. AlertStale
+	Key      string // key of the sensor
+	Location string
+	Message  string
+	Resolved bool
+}
+
+// Sink consumes the events of the bus, e.g. by exporting readings to
+// Prometheus or sending push notifications.
+type Sink interface {
+	Handle(e Event)
+}
+
+// Bus distributes events to its subscribers. Every subscriber has its own
+// buffered channel, so a slow subscriber never blocks the publisher or other
+// subscribers. Events are dropped for a subscriber whose buffer is full.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string]chan Event
+}
+
+// NewBus creates an event bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{
+		subs: map[string]chan Event{},
+	}
+}
+
+// Subscribe returns a channel that receives all events published from now on.
+// The name identifies the subscriber in logs and metrics.
+func (b *Bus) Subscribe(name string, buffer int) <-chan Event {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if old, ok := b.subs[name]; ok {
+		close(old)
+	}
+	b.subs[name] = ch
+	return ch
+}
+
+// Unsubscribe removes the named subscriber and closes its channel.
+func (b *Bus) Unsubscribe(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ch, ok := b.subs[name]; ok {
+		close(ch)
+		delete(b.subs, name)
+	}
+}
+
+// Attach subscribes the sink and lets it handle the events in its own goroutine
+// until it is unsubscribed.
+func (b *Bus) Attach(name string, buffer int, s Sink) {
+	ch := b.Subscribe(name, buffer)
+	go func() {
+		for e := range ch {
+			s.Handle(e)
+		}
+	}()
+}
+
+// Publish sends the event to all subscribers without blocking.
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for name, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			log.Printf("Sink '%s' is too slow, dropping event\n", name)
+			eventsDropped.With(prometheus.Labels{
+				SinkName: name,
+			}).Inc()
+		}
+	}
+}
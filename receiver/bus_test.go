@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sinkFunc func(Event)
+
+func (f sinkFunc) Handle(e Event) { f(e) }
+
+func TestBus_slowSubscriberDoesNotBlock(t *testing.T) {
+	b := NewBus()
+	slow := b.Subscribe("slow", 1)
+
+	received := make(chan Event, 10)
+	b.Attach("fast", 10, sinkFunc(func(e Event) {
+		received <- e
+	}))
+
+	for i := 0; i < 3; i++ {
+		b.Publish(Event{Kind: ButtonEvent})
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case e := <-received:
+			assert.Equal(t, ButtonEvent, e.Kind)
+			assert.False(t, e.Time.IsZero())
+		case <-time.After(time.Second):
+			t.Fatal("fast sink didn't receive all events")
+		}
+	}
+	assert.Len(t, slow, 1, "events beyond the buffer are dropped")
+
+	b.Unsubscribe("slow")
+	_, open := <-slow
+	assert.True(t, open, "buffered event is still delivered")
+	_, open = <-slow
+	assert.False(t, open)
+}
@@ -0,0 +1,26 @@
+package main
+
+// Calibration corrects a measurement of a sensor that is off:
+// the value is multiplied by the scale and the offset is added.
+type Calibration struct {
+	Offset float64 `json:"offset,omitempty"`
+	Scale  float64 `json:"scale,omitempty"` // 0 means 1
+}
+
+// Apply returns the calibrated value.
+func (c Calibration) Apply(value float64) float64 {
+	if c.Scale != 0 {
+		value *= c.Scale
+	}
+	return round(value+c.Offset, 2)
+}
+
+// calibrate applies the calibrations to the measurements of a reading,
+// which are keyed by the name of the measurement.
+func calibrate(r *Reading, calibrations map[string]Calibration) {
+	for i, m := range r.Measurements {
+		if c, ok := calibrations[m.Name]; ok {
+			r.Measurements[i].Value = c.Apply(m.Value)
+		}
+	}
+}
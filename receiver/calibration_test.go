@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrate(t *testing.T) {
+	r := &Reading{}
+	r.Set(FieldTemperature, 21.3, UnitCelsius)
+	r.Set(FieldHumidity, 40, UnitPercent)
+	r.Set(FieldDistance, 120, UnitCentimeter)
+
+	calibrate(r, map[string]Calibration{
+		FieldTemperature: {Offset: -1.1},
+		FieldHumidity:    {Scale: 1.05, Offset: 2},
+	})
+
+	v, _ := r.Value(FieldTemperature)
+	assert.Equal(t, 20.2, v)
+	v, _ = r.Value(FieldHumidity)
+	assert.Equal(t, 44.0, v)
+	v, _ = r.Value(FieldDistance)
+	assert.Equal(t, 120.0, v)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Types of checksums a protocol can declare.
+const (
+	ChecksumSum    = "sum"    // sum of the data in groups of Bits bits, e.g. nibbles
+	ChecksumXOR    = "xor"    // XOR of the data in groups of Bits bits
+	ChecksumCRC8   = "crc8"   // CRC-8 of the data bytes with polynomial Poly and initial value Init
+	ChecksumParity = "parity" // single parity bit, even unless Odd is set
+)
+
+// Checksum declares integrity bits of a protocol
+// that are verified before a frame is decoded.
+type Checksum struct {
+	Type   string `yaml:"type"`
+	Offset int    `yaml:"offset"` // first bit of the checked data
+	Width  int    `yaml:"width"`  // number of bits of the checked data
+	At     int    `yaml:"at"`     // first bit of the checksum
+	Bits   int    `yaml:"bits"`   // width of the checksum, 0 means 4 for sum and xor, 8 for crc8 and 1 for parity
+	Poly   uint8  `yaml:"poly"`   // polynomial of crc8
+	Init   uint8  `yaml:"init"`   // initial value of crc8
+	Odd    bool   `yaml:"odd"`    // odd instead of even parity
+}
+
+// ChecksumError is returned when the checksum of a frame doesn't match its data.
+type ChecksumError struct {
+	Type           string
+	Want, Computed uint64
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("Checksum %s mismatch: frame has %d, computed %d", e.Type, e.Want, e.Computed)
+}
+
+func (c *Checksum) bits() int {
+	if c.Bits > 0 {
+		return c.Bits
+	}
+	switch c.Type {
+	case ChecksumCRC8:
+		return 8
+	case ChecksumParity:
+		return 1
+	}
+	return 4
+}
+
+func (c *Checksum) validate() error {
+	switch c.Type {
+	case ChecksumSum, ChecksumXOR:
+		if c.Width%c.bits() != 0 {
+			return fmt.Errorf("%s checksum data must be a multiple of %d bits", c.Type, c.bits())
+		}
+	case ChecksumCRC8:
+		if c.Width%8 != 0 || c.bits() != 8 {
+			return fmt.Errorf("crc8 checksum needs whole data bytes and 8 checksum bits")
+		}
+	case ChecksumParity:
+		if c.bits() != 1 {
+			return fmt.Errorf("parity checksum has exactly one bit")
+		}
+	default:
+		return fmt.Errorf("unknown checksum type '%s'", c.Type)
+	}
+	if c.Offset < 0 || c.Width <= 0 || c.At < 0 || c.bits() > 64 {
+		return fmt.Errorf("%s checksum has an invalid offset or width", c.Type)
+	}
+	return nil
+}
+
+// Verify checks the checksum of the binary representation of a frame.
+func (c *Checksum) Verify(binSeq string) error {
+	n := c.bits()
+	if c.Offset+c.Width > len(binSeq) || c.At+n > len(binSeq) {
+		return fmt.Errorf("Checksum %s is out of range of %d bits", c.Type, len(binSeq))
+	}
+	want, err := strconv.ParseUint(binSeq[c.At:c.At+n], 2, 64)
+	if err != nil {
+		return err
+	}
+	data := binSeq[c.Offset : c.Offset+c.Width]
+
+	var computed uint64
+	switch c.Type {
+	case ChecksumSum, ChecksumXOR:
+		for i := 0; i < len(data); i += n {
+			v, err := strconv.ParseUint(data[i:i+n], 2, 64)
+			if err != nil {
+				return err
+			}
+			if c.Type == ChecksumSum {
+				computed += v
+			} else {
+				computed ^= v
+			}
+		}
+		if n < 64 {
+			computed &= 1<<uint(n) - 1
+		}
+	case ChecksumCRC8:
+		crc := c.Init
+		for i := 0; i < len(data); i += 8 {
+			v, err := strconv.ParseUint(data[i:i+8], 2, 8)
+			if err != nil {
+				return err
+			}
+			crc = crc8(crc, byte(v), c.Poly)
+		}
+		computed = uint64(crc)
+	case ChecksumParity:
+		for _, b := range data {
+			if b == '1' {
+				computed ^= 1
+			}
+		}
+		if c.Odd {
+			computed ^= 1
+		}
+	}
+
+	if computed != want {
+		return &ChecksumError{c.Type, want, computed}
+	}
+	return nil
+}
+
+// crc8 adds a byte to a CRC-8 computed MSB first.
+func crc8(crc, b, poly uint8) uint8 {
+	crc ^= b
+	for i := 0; i < 8; i++ {
+		if crc&0x80 != 0 {
+			crc = crc<<1 ^ poly
+		} else {
+			crc <<= 1
+		}
+	}
+	return crc
+}
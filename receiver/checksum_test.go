@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bitString(data ...byte) string {
+	var b strings.Builder
+	for _, d := range data {
+		fmt.Fprintf(&b, "%08b", d)
+	}
+	return b.String()
+}
+
+func TestChecksum_Verify(t *testing.T) {
+	sum := &Checksum{Type: ChecksumSum, Width: 8, At: 8}
+	assert.NoError(t, sum.Verify(bitString(0x35)+"1000"))
+	assert.Error(t, sum.Verify(bitString(0x35)+"1001"))
+
+	xor := &Checksum{Type: ChecksumXOR, Width: 8, At: 8}
+	assert.NoError(t, xor.Verify(bitString(0x35)+"0110"))
+
+	crc := &Checksum{Type: ChecksumCRC8, Width: 72, At: 72, Poly: 0x07}
+	assert.NoError(t, crc.Verify(bitString([]byte("123456789")...)+bitString(0xF4)))
+	err := crc.Verify(bitString([]byte("123456788")...) + bitString(0xF4))
+	assert.IsType(t, &ChecksumError{}, err)
+
+	even := &Checksum{Type: ChecksumParity, Width: 4, At: 4}
+	assert.NoError(t, even.Verify("10111"))
+	odd := &Checksum{Type: ChecksumParity, Width: 4, At: 4, Odd: true}
+	assert.NoError(t, odd.Verify("10101"))
+
+	assert.Error(t, sum.Verify("0101"), "out of range")
+}
+
+func TestProtocol_DecodeVerifiesChecksums(t *testing.T) {
+	p := &Protocol{
+		Fields:    []Field{{Name: FieldTemperature, Offset: 0, Width: 4}},
+		Checksums: []Checksum{{Type: ChecksumParity, Width: 4, At: 4}},
+	}
+
+	_, err := p.Decode("01101")
+	assert.IsType(t, &ChecksumError{}, err)
+
+	r, err := p.Decode("01100")
+	assert.NoError(t, err)
+	assert.Equal(t, []Measurement{{Name: FieldTemperature, Value: 6}}, r.Measurements)
+}
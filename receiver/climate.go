@@ -0,0 +1,65 @@
+package main
+
+import "math"
+
+// Measurements derived from the temperature and humidity of a reading.
+const (
+	FieldDewPoint          = "dewpoint"
+	FieldAbsoluteHumidity  = "absolute_humidity"
+	FieldHeatIndex         = "heat_index"
+	UnitGramsPerCubicMeter = "g/m3"
+)
+
+// Coefficients of the Magnus formula over water.
+const (
+	magnusA = 17.62
+	magnusB = 243.12 // °C
+)
+
+// DewPoint returns the temperature in °C at which the air
+// with the given temperature and relative humidity gets saturated.
+func DewPoint(temp, humid float64) float64 {
+	gamma := math.Log(humid/100) + magnusA*temp/(magnusB+temp)
+	return magnusB * gamma / (magnusA - gamma)
+}
+
+// AbsoluteHumidity returns the water vapour in the air in g/m³.
+func AbsoluteHumidity(temp, humid float64) float64 {
+	saturation := 6.112 * math.Exp(magnusA*temp/(magnusB+temp)) // hPa
+	return saturation * humid * 2.1674 / (273.15 + temp)
+}
+
+// HeatIndex returns the temperature in °C as perceived by humans
+// using the regression of the US National Weather Service.
+func HeatIndex(temp, humid float64) float64 {
+	t := temp*9/5 + 32
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + humid*0.094)
+	if (hi+t)/2 >= 80 {
+		hi = -42.379 + 2.04901523*t + 10.14333127*humid -
+			0.22475541*t*humid - 0.00683783*t*t - 0.05481717*humid*humid +
+			0.00122874*t*t*humid + 0.00085282*t*humid*humid - 0.00000199*t*t*humid*humid
+		switch {
+		case humid < 13 && t >= 80 && t <= 112:
+			hi -= (13 - humid) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+		case humid > 85 && t >= 80 && t <= 87:
+			hi += (humid - 85) / 10 * (87 - t) / 5
+		}
+	}
+	return (hi - 32) * 5 / 9
+}
+
+// applyClimate adds the dew point, absolute humidity and heat index
+// to a reading with a temperature and humidity.
+func applyClimate(r *Reading) {
+	temp, ok := r.Value(FieldTemperature)
+	if !ok {
+		return
+	}
+	humid, ok := r.Value(FieldHumidity)
+	if !ok || humid <= 0 || humid > 100 {
+		return
+	}
+	r.Set(FieldDewPoint, round(DewPoint(temp, humid), 1), UnitCelsius)
+	r.Set(FieldAbsoluteHumidity, round(AbsoluteHumidity(temp, humid), 1), UnitGramsPerCubicMeter)
+	r.Set(FieldHeatIndex, round(HeatIndex(temp, humid), 1), UnitCelsius)
+}
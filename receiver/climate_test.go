@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyClimate(t *testing.T) {
+	r := &Reading{}
+	r.Set(FieldTemperature, 20, UnitCelsius)
+	r.Set(FieldHumidity, 50, UnitPercent)
+	applyClimate(r)
+
+	v, _ := r.Value(FieldDewPoint)
+	assert.Equal(t, 9.3, v)
+	v, _ = r.Value(FieldAbsoluteHumidity)
+	assert.Equal(t, 8.6, v)
+	v, _ = r.Value(FieldHeatIndex)
+	assert.Equal(t, 19.4, v)
+
+	assert.InDelta(t, 32.9, HeatIndex(30, 60), 0.1)
+
+	r = &Reading{}
+	r.Set(FieldDistance, 100, UnitCentimeter)
+	applyClimate(r)
+	assert.Len(t, r.Measurements, 1)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the configuration of the receiver. Settings in the config
+// file take precedence over the flags they correspond to.
+type Config struct {
+	Input             string                  `yaml:"input"` // see ParseSource
+	Serial            SerialConfig            `yaml:"serial"`
+	ReadTimeout       time.Duration           `yaml:"readTimeout"` // of the input, see ReceiverConfig
+	Receivers         []ReceiverConfig        `yaml:"receivers"`   // used instead of input and serial
+	ListenAddress     string                  `yaml:"listenAddress"`
+	GRPCListenAddress string                  `yaml:"grpcListenAddress"`
+	Redis             string                  `yaml:"redis"`
+	PushPort          string                  `yaml:"pushPort"`
+	Sinks             []string                `yaml:"sinks"`
+	StaleAfter        time.Duration           `yaml:"staleAfter"`
+	MaxRates          map[string]string       `yaml:"maxRates"`
+	LocationFile      string                  `yaml:"locationFile"`
+	Sensors           map[string]SensorConfig `yaml:"sensors"` // added for sensors not in the location file
+	ProtocolFile      string                  `yaml:"protocolFile"`
+	Protocols         map[string]*Protocol    `yaml:"protocols"` // merged over those of the protocol file
+	Recording         RecordingConfig         `yaml:"recording"`
+}
+
+// ReceiverConfig is the configuration of one of several receivers.
+type ReceiverConfig struct {
+	Name        string        `yaml:"name"` // defaults to the description of the input
+	Input       string        `yaml:"input"`
+	Serial      SerialConfig  `yaml:"serial"`
+	ReadTimeout time.Duration `yaml:"readTimeout"` // reopen the input if nothing was read for this long, 0 disables it and uses the one of the serial port
+}
+
+// LoadConfig reads the config file at path on top of the defaults and
+// validates the result. An empty path results in the defaults.
+func LoadConfig(path string, defaults Config) (*Config, error) {
+	c := defaults
+	c.MaxRates = make(map[string]string, len(defaults.MaxRates))
+	for name, rate := range defaults.MaxRates {
+		c.MaxRates[name] = rate
+	}
+	c.Sensors = make(map[string]SensorConfig, len(defaults.Sensors))
+	for id, s := range defaults.Sensors {
+		c.Sensors[id] = s
+	}
+
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read config file")
+		}
+		if err := yaml.UnmarshalStrict(b, &c); err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse config file '%s'", path)
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "Invalid configuration")
+	}
+	return &c, nil
+}
+
+// Validate checks whether the receiver can be started with the configuration.
+func (c *Config) Validate() error {
+	names := map[string]bool{}
+	for _, r := range c.receivers() {
+		s, err := ParseSource(r.Input, r.Serial)
+		if err != nil {
+			return err
+		}
+		name := r.Name
+		if name == "" {
+			name = s.String()
+		}
+		if names[name] {
+			return fmt.Errorf("receiver '%s' is configured twice", name)
+		}
+		names[name] = true
+		if r.ReadTimeout < 0 {
+			return fmt.Errorf("readTimeout of receiver '%s' must not be negative", name)
+		}
+	}
+	if c.ListenAddress == "" || c.GRPCListenAddress == "" {
+		return fmt.Errorf("listenAddress and grpcListenAddress must be set")
+	}
+	for _, sink := range c.Sinks {
+		switch sink {
+		case SinkMetrics:
+		case SinkPush:
+			if c.Redis == "" || c.PushPort == "" {
+				return fmt.Errorf("redis and pushPort must be set for the push sink")
+			}
+		default:
+			return fmt.Errorf("unknown sink '%s'", sink)
+		}
+	}
+	if c.StaleAfter != 0 && c.StaleAfter < MinStaleAfter {
+		return fmt.Errorf("staleAfter must be 0 or at least %v", MinStaleAfter)
+	}
+	if _, err := ParseMaxRates(c.MaxRates); err != nil {
+		return err
+	}
+	for id, s := range c.Sensors {
+		if s.Location == "" {
+			return fmt.Errorf("sensor '%s' has no location", id)
+		}
+		if err := s.validate(); err != nil {
+			return errors.Wrapf(err, "Invalid configuration of sensor '%s'", id)
+		}
+	}
+	if c.Recording.MaxSize < 0 || c.Recording.MaxFiles < 0 {
+		return fmt.Errorf("recording.maxSize and recording.maxFiles must not be negative")
+	}
+	_, err := c.protocols()
+	return err
+}
+
+// receivers returns the configured receivers, which is only the one
+// given by input and serial unless several receivers are configured.
+func (c *Config) receivers() []ReceiverConfig {
+	if len(c.Receivers) == 0 {
+		return []ReceiverConfig{{Input: c.Input, Serial: c.Serial, ReadTimeout: c.ReadTimeout}}
+	}
+	receivers := make([]ReceiverConfig, len(c.Receivers))
+	for i, r := range c.Receivers {
+		if r.Serial.BaudRate == 0 {
+			r.Serial.BaudRate = DefaultBaudRate
+		}
+		receivers[i] = r
+	}
+	return receivers
+}
+
+// protocols returns the built-in protocols merged with those
+// of the protocol file and the config file.
+func (c *Config) protocols() (map[string]*Protocol, error) {
+	protocols := Protocols()
+	if c.ProtocolFile != "" {
+		p, err := LoadProtocols(c.ProtocolFile)
+		if err != nil {
+			return nil, err
+		}
+		protocols = p
+	}
+	return MergeProtocols(protocols, c.Protocols)
+}
+
+// restartRequired returns the settings that changed in the new config
+// but are only applied when the receiver is restarted.
+func (c *Config) restartRequired(n *Config) []string {
+	var changed []string
+	settings := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"input", c.Input, n.Input},
+		{"serial", c.Serial, n.Serial},
+		{"readTimeout", c.ReadTimeout, n.ReadTimeout},
+		{"receivers", c.Receivers, n.Receivers},
+		{"listenAddress", c.ListenAddress, n.ListenAddress},
+		{"grpcListenAddress", c.GRPCListenAddress, n.GRPCListenAddress},
+		{"redis", c.Redis, n.Redis},
+		{"pushPort", c.PushPort, n.PushPort},
+		{"sinks", c.Sinks, n.Sinks},
+		{"staleAfter", c.StaleAfter, n.StaleAfter},
+		{"locationFile", c.LocationFile, n.LocationFile},
+		{"recording.path", c.Recording.Path, n.Recording.Path},
+		{"recording.maxSize", c.Recording.MaxSize, n.Recording.MaxSize},
+		{"recording.maxFiles", c.Recording.MaxFiles, n.Recording.MaxFiles},
+	}
+	for _, s := range settings {
+		if !reflect.DeepEqual(s.old, s.new) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+
+	defaults := Config{
+		Serial:            SerialConfig{Device: "/dev/ttyUSB0", BaudRate: DefaultBaudRate},
+		ListenAddress:     ":8080",
+		GRPCListenAddress: ":8082",
+		Redis:             "localhost:6379",
+		PushPort:          "8081",
+		Sinks:             []string{SinkMetrics, SinkPush},
+		Sensors:           map[string]SensorConfig{"200": {Location: "Grube"}},
+	}
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+serial:
+  device: /dev/ttyACM0
+sinks: [metrics]
+staleAfter: 30m
+maxRates:
+  temperature: 2
+sensors:
+  "2454":
+    location: kitchen
+    calibration:
+      temperature: {offset: -0.5}
+protocols:
+  doorbell-old-2:
+    disabled: true
+`), 0644))
+
+	c, err := LoadConfig(path, defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/ttyACM0", c.Serial.Device)
+	assert.Equal(t, DefaultBaudRate, c.Serial.BaudRate)
+	assert.Equal(t, ":8080", c.ListenAddress, "flags are used for missing settings")
+	assert.Equal(t, []string{SinkMetrics}, c.Sinks)
+	assert.Equal(t, 30*time.Minute, c.StaleAfter)
+	assert.Equal(t, "2", c.MaxRates[FieldTemperature])
+	assert.Equal(t, "Grube", c.Sensors["200"].Location)
+	assert.Equal(t, -0.5, c.Sensors["2454"].Calibration[FieldTemperature].Offset)
+	assert.Len(t, defaults.Sensors, 1, "defaults are not modified")
+
+	protocols, err := c.protocols()
+	assert.NoError(t, err)
+	assert.NotContains(t, protocols, "doorbell-old-2")
+	assert.Contains(t, protocols, "protocol1")
+
+	assert.Equal(t, []string{"serial", "sinks", "staleAfter"}, defaults.restartRequired(c))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("staleAfter: 1ns\n"), 0644))
+	_, err = LoadConfig(path, defaults)
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("sinks: [mail]\n"), 0644))
+	_, err = LoadConfig(path, defaults)
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("sensors: {\"1\": {location: cellar, tank: {shape: cube}}}\n"), 0644))
+	_, err = LoadConfig(path, defaults)
+	assert.Error(t, err)
+}
+
+func TestConfig_receivers(t *testing.T) {
+	c := Config{
+		Input:             "serial",
+		Serial:            SerialConfig{Device: "/dev/ttyUSB0", BaudRate: DefaultBaudRate},
+		ListenAddress:     ":8080",
+		GRPCListenAddress: ":8082",
+	}
+	assert.Equal(t, []ReceiverConfig{{Input: "serial", Serial: c.Serial}}, c.receivers())
+
+	c.Receivers = []ReceiverConfig{
+		{Name: "attic", Serial: SerialConfig{VID: "1a86", PID: "7523"}},
+		{Input: "tcp://cellar:2000"},
+	}
+	receivers := c.receivers()
+	assert.Len(t, receivers, 2)
+	assert.Equal(t, DefaultBaudRate, receivers[0].Serial.BaudRate)
+	assert.NoError(t, c.Validate())
+
+	c.Receivers = append(c.Receivers, ReceiverConfig{Input: "tcp://cellar:2000"})
+	assert.Error(t, c.Validate(), "same receiver twice")
+}
+
+func TestSensorIDs(t *testing.T) {
+	parse := func(args ...string) map[string]SensorConfig {
+		app := kingpin.New("receiver", "")
+		ids := sensorIDs(app)
+		_, err := app.Parse(args)
+		assert.NoError(t, err)
+		return sensorConfigs(*ids)
+	}
+
+	assert.Equal(t, map[string]SensorConfig{"200": {Location: "Grube"}}, parse())
+	assert.Equal(t, map[string]SensorConfig{
+		"201": {Location: "Zisterne"},
+		"202": {Location: "Grube"},
+	}, parse("201=Zisterne", "202=Grube"), "given sensors replace the default pit sensor")
+}
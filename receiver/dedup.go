@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// echoWindow is the time within which the same frame heard by
+// several receivers is considered the same transmission.
+const echoWindow = 500 * time.Millisecond
+
+// DefaultDedupWindow is the time within which a repeated frame is
+// considered a retransmission for protocols that don't declare their own.
+const DefaultDedupWindow = 2 * time.Second
+
+// Deduplicator suppresses frames that are retransmitted within a short
+// window. 433 MHz devices repeat every frame several times, and the Arduino
+// reports every repeat as a separate signal. It is safe for concurrent use.
+type Deduplicator struct {
+	mu        sync.Mutex
+	expiry    map[string]time.Time
+	heard     map[string]heard
+	lastPrune time.Time
+}
+
+// heard is the receiver that last heard a frame and when it's
+// no longer considered the same transmission.
+type heard struct {
+	receiver string
+	expiry   time.Time
+}
+
+// NewDeduplicator creates a Deduplicator that hasn't seen any frames yet.
+func NewDeduplicator() *Deduplicator {
+	return &Deduplicator{
+		expiry: map[string]time.Time{},
+		heard:  map[string]heard{},
+	}
+}
+
+// Duplicate reports whether a frame with the same key was seen within the
+// window before t. Every frame, including duplicates, extends the window,
+// so a long burst of repeats is suppressed as a whole.
+func (d *Deduplicator) Duplicate(key string, window time.Duration, t time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t.Sub(d.lastPrune) > time.Minute {
+		d.prune(t)
+	}
+
+	expiry, seen := d.expiry[key]
+	d.expiry[key] = t.Add(window)
+	return seen && t.Before(expiry)
+}
+
+// Echo reports whether a frame with the same key was heard by another
+// receiver within the window before t, i.e. the same transmission reached
+// several receivers. Unlike Duplicate, repeats heard by the same receiver
+// are not suppressed.
+func (d *Deduplicator) Echo(key, receiver string, window time.Duration, t time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t.Sub(d.lastPrune) > time.Minute {
+		d.prune(t)
+	}
+
+	h, seen := d.heard[key]
+	if seen && t.Before(h.expiry) && h.receiver != receiver {
+		return true
+	}
+	d.heard[key] = heard{receiver, t.Add(window)}
+	return false
+}
+
+// prune forgets all frames whose window has passed.
+func (d *Deduplicator) prune(t time.Time) {
+	for key, expiry := range d.expiry {
+		if !t.Before(expiry) {
+			delete(d.expiry, key)
+		}
+	}
+	for key, h := range d.heard {
+		if !t.Before(h.expiry) {
+			delete(d.heard, key)
+		}
+	}
+	d.lastPrune = t
+}
+
+// dedupWindow returns the window within which repeated frames are suppressed,
+// or 0 if the protocol doesn't want them to be suppressed.
+func (p *Protocol) dedupWindow() time.Duration {
+	switch {
+	case p.DedupWindow < 0:
+		return 0
+	case p.DedupWindow == 0:
+		return DefaultDedupWindow
+	}
+	return p.DedupWindow
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeduplicator(t *testing.T) {
+	d := NewDeduplicator()
+	start := time.Now()
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	assert.False(t, d.Duplicate("a:0101", time.Second, at(0)))
+	assert.True(t, d.Duplicate("a:0101", time.Second, at(100)))
+	assert.False(t, d.Duplicate("a:0110", time.Second, at(150)), "different payload")
+	assert.False(t, d.Duplicate("b:0101", time.Second, at(200)), "different protocol")
+	assert.True(t, d.Duplicate("a:0101", time.Second, at(1050)), "repeats extend the window")
+	assert.False(t, d.Duplicate("a:0101", time.Second, at(2100)))
+}
+
+func TestProtocol_dedupWindow(t *testing.T) {
+	assert.Equal(t, DefaultDedupWindow, (&Protocol{}).dedupWindow())
+	assert.Equal(t, 5*time.Second, (&Protocol{DedupWindow: 5 * time.Second}).dedupWindow())
+	assert.Equal(t, time.Duration(0), (&Protocol{DedupWindow: -1}).dedupWindow())
+}
+
+func TestDeduplicator_Echo(t *testing.T) {
+	d := NewDeduplicator()
+	start := time.Now()
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	assert.False(t, d.Echo("a:0101", "attic", echoWindow, at(0)))
+	assert.True(t, d.Echo("a:0101", "cellar", echoWindow, at(20)))
+	assert.False(t, d.Echo("a:0101", "attic", echoWindow, at(40)), "repeats of the same receiver")
+	assert.False(t, d.Echo("a:0101", "cellar", echoWindow, at(1000)), "next transmission")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// unmappedMaxAge is the time after which a sensor that hasn't been seen
+// anymore is forgotten, e.g. a random id produced by a corrupted frame.
+const unmappedMaxAge = 24 * time.Hour
+
+// SeenSensor is a sensor that sent readings but isn't mapped to a location.
+type SeenSensor struct {
+	ID           string        `json:"id"`
+	Key          string        `json:"key"` // what the sensor must be mapped by
+	Protocol     string        `json:"protocol"`
+	Channel      int           `json:"channel,omitempty"`
+	FirstSeen    time.Time     `json:"firstSeen"`
+	LastSeen     time.Time     `json:"lastSeen"`
+	Count        int           `json:"count"`
+	LowBattery   bool          `json:"lowBattery"`
+	Measurements []Measurement `json:"measurements,omitempty"`
+}
+
+// Discovery keeps track of sensors that are received but not mapped to a
+// location, e.g. a sensor that picked a new id after a battery change.
+// It is safe for concurrent use.
+type Discovery struct {
+	mu      sync.Mutex
+	sensors map[string]*SeenSensor
+}
+
+// NewDiscovery creates a Discovery that hasn't seen any sensors yet.
+func NewDiscovery() *Discovery {
+	return &Discovery{
+		sensors: map[string]*SeenSensor{},
+	}
+}
+
+// Seen records a reading of an unmapped sensor.
+func (d *Discovery) Seen(r *Reading) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := r.Protocol + ":" + r.SensorID
+	s, ok := d.sensors[key]
+	if !ok {
+		log.Printf("New sensor %s of protocol %s, it needs a location to be exported\n", r.SensorID, r.Protocol)
+		s = &SeenSensor{
+			ID:        r.SensorID,
+			Protocol:  r.Protocol,
+			FirstSeen: r.Time,
+		}
+		d.sensors[key] = s
+	}
+	s.Key = r.Key
+	s.Channel = r.Channel
+	s.LastSeen = r.Time
+	s.Count++
+	s.LowBattery = r.LowBattery
+	s.Measurements = r.Measurements
+
+	for key, s := range d.sensors {
+		if r.Time.Sub(s.LastSeen) > unmappedMaxAge {
+			delete(d.sensors, key)
+		}
+	}
+}
+
+// Forget removes the sensors with the given key,
+// e.g. after it has been mapped to a location.
+func (d *Discovery) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k, s := range d.sensors {
+		if s.Key == key {
+			delete(d.sensors, k)
+		}
+	}
+}
+
+// List returns all unmapped sensors, most recently seen first.
+func (d *Discovery) List() []SeenSensor {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]SeenSensor, 0, len(d.sensors))
+	for _, s := range d.sensors {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastSeen.After(list[j].LastSeen)
+	})
+	return list
+}
+
+// ServeHTTP responds with the list of unmapped sensors as JSON.
+func (d *Discovery) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.List()); err != nil {
+		log.Println(err)
+	}
+}
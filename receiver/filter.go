@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMaxRates are the maximum changes per minute of measurements
+// that are accepted by the spike filter unless configured otherwise.
+var DefaultMaxRates = map[string]float64{
+	FieldTemperature: 5,
+	FieldHumidity:    20,
+	FieldDistance:    50,
+}
+
+// sampleExpiry is the age after which the last accepted value of a
+// measurement is forgotten. By then the allowed change is so large that
+// it wouldn't reject anything anyway.
+const sampleExpiry = 30 * time.Minute
+
+// sample is the last accepted value of a measurement.
+type sample struct {
+	value float64
+	time  time.Time
+}
+
+// SpikeFilter rejects readings with a measurement that changed faster than
+// its maximum rate compared to the last accepted value of the same sensor.
+// The allowed change grows with the time since that value was accepted,
+// but is never less than the maximum rate for one minute, so frequent
+// readings aren't rejected for ordinary noise. It is safe for concurrent use.
+type SpikeFilter struct {
+	mu        sync.Mutex
+	maxRates  map[string]float64
+	last      map[string]map[string]sample
+	lastPrune time.Time
+}
+
+// NewSpikeFilter creates a filter with the given maximum changes per minute,
+// keyed by measurement name. Measurements without a maximum aren't checked.
+func NewSpikeFilter(maxRates map[string]float64) *SpikeFilter {
+	return &SpikeFilter{
+		maxRates: maxRates,
+		last:     map[string]map[string]sample{},
+	}
+}
+
+// SetMaxRates replaces the maximum changes per minute.
+func (f *SpikeFilter) SetMaxRates(maxRates map[string]float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maxRates = maxRates
+}
+
+// ParseMaxRates parses maximum rates given as strings, e.g. from flags,
+// and merges them into the defaults.
+func ParseMaxRates(rates map[string]string) (map[string]float64, error) {
+	result := make(map[string]float64, len(DefaultMaxRates)+len(rates))
+	for name, rate := range DefaultMaxRates {
+		result[name] = rate
+	}
+	for name, s := range rates {
+		rate, err := strconv.ParseFloat(s, 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("Invalid maximum rate '%s' for %s", s, name)
+		}
+		result[name] = rate
+	}
+	return result, nil
+}
+
+// Accept checks the reading against the last accepted values of its sensor.
+// If it is accepted, its values become the new reference.
+func (f *SpikeFilter) Accept(r *Reading) bool {
+	key := r.Protocol + ":" + r.SensorID
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Time.Sub(f.lastPrune) > time.Minute {
+		f.prune(r.Time)
+	}
+
+	last := f.last[key]
+	for _, m := range r.Measurements {
+		rate, ok := f.maxRates[m.Name]
+		if !ok || rate == 0 {
+			continue
+		}
+		prev, ok := last[m.Name]
+		if !ok || r.Time.Sub(prev.time) > sampleExpiry {
+			continue
+		}
+		minutes := math.Max(r.Time.Sub(prev.time).Minutes(), 1)
+		if delta := math.Abs(m.Value - prev.value); delta > rate*minutes {
+			log.Printf("Rejecting %s of sensor %s: %v changed by %.1f within %v\n",
+				m.Name, key, m.Value, delta, r.Time.Sub(prev.time).Round(time.Second))
+			readingsRejected.With(prometheus.Labels{
+				ProtocolName:    r.Protocol,
+				MeasurementName: m.Name,
+			}).Inc()
+			return false
+		}
+	}
+
+	if last == nil {
+		last = map[string]sample{}
+		f.last[key] = last
+	}
+	for _, m := range r.Measurements {
+		last[m.Name] = sample{m.Value, r.Time}
+	}
+	return true
+}
+
+// prune forgets all values accepted more than sampleExpiry before t,
+// and the sensors without any values left.
+func (f *SpikeFilter) prune(t time.Time) {
+	for key, last := range f.last {
+		for name, s := range last {
+			if t.Sub(s.time) > sampleExpiry {
+				delete(last, name)
+			}
+		}
+		if len(last) == 0 {
+			delete(f.last, key)
+		}
+	}
+	f.lastPrune = t
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpikeFilter(t *testing.T) {
+	f := NewSpikeFilter(map[string]float64{FieldTemperature: 2})
+	start := time.Now()
+	reading := func(minutes int, temp float64) *Reading {
+		return &Reading{
+			Protocol:     "protocol1",
+			SensorID:     "2454",
+			Time:         start.Add(time.Duration(minutes) * time.Minute),
+			Measurements: []Measurement{{Name: FieldTemperature, Value: temp}},
+		}
+	}
+
+	assert.True(t, f.Accept(reading(0, 20)))
+	assert.True(t, f.Accept(reading(0, 21.5)), "within the rate of one minute")
+	assert.False(t, f.Accept(reading(1, 50)), "spike")
+	assert.True(t, f.Accept(reading(2, 22)), "compared to the last accepted value")
+	assert.True(t, f.Accept(reading(12, 35)), "allowed change grows with time")
+	assert.True(t, f.Accept(reading(60, 0)), "expired values are forgotten")
+	assert.Len(t, f.last, 1)
+
+	other := reading(120, 10)
+	other.SensorID = "7"
+	assert.True(t, f.Accept(other))
+	assert.Len(t, f.last, 1, "sensors that stopped sending are pruned")
+}
+
+func TestParseMaxRates(t *testing.T) {
+	rates, err := ParseMaxRates(map[string]string{FieldHumidity: "10", "pressure": "1.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, rates[FieldHumidity])
+	assert.Equal(t, 1.5, rates["pressure"])
+	assert.Equal(t, DefaultMaxRates[FieldTemperature], rates[FieldTemperature])
+
+	_, err = ParseMaxRates(map[string]string{FieldHumidity: "x"})
+	assert.Error(t, err)
+}
+
+func TestPublishReading_rejected(t *testing.T) {
+	defer func(l *Locations, r *Registry, f *SpikeFilter, d *Discovery) {
+		locations, registry, spikeFilter, discovery = l, r, f, d
+	}(locations, registry, spikeFilter, discovery)
+	var err error
+	locations, err = NewLocations("", map[string]string{"2454": "kitchen"})
+	assert.NoError(t, err)
+	registry, err = NewRegistry(nil)
+	assert.NoError(t, err)
+	spikeFilter = NewSpikeFilter(DefaultMaxRates)
+	discovery = NewDiscovery()
+
+	start := time.Now()
+	reading := func(seconds int, temp float64) *Reading {
+		return &Reading{
+			Protocol:     "grpc",
+			SensorID:     "2454",
+			Time:         start.Add(time.Duration(seconds) * time.Second),
+			Measurements: []Measurement{{FieldTemperature, temp, UnitCelsius}},
+		}
+	}
+	assert.True(t, PublishReading(reading(0, 20)))
+	assert.False(t, PublishReading(reading(10, 60)), "spike")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Identity rules define which key a sensor is mapped to a location by.
+const (
+	// IdentityID maps sensors by their id. Sensors that pick a random id,
+	// e.g. GT-WT-01 after a battery change, lose their location.
+	IdentityID = "id"
+	// IdentityChannel maps sensors by their channel.
+	IdentityChannel = "channel"
+	// IdentityProtocolChannel maps sensors by their protocol and channel,
+	// for channels used by sensors of several protocols.
+	IdentityProtocolChannel = "protocol-channel"
+)
+
+func validateIdentity(identity string) error {
+	switch identity {
+	case "", IdentityID, IdentityChannel, IdentityProtocolChannel:
+		return nil
+	}
+	return fmt.Errorf("unknown identity '%s'", identity)
+}
+
+// SensorKey returns the key the sensor of a reading is mapped to a location by.
+func SensorKey(r *Reading, identity string) string {
+	switch identity {
+	case IdentityChannel:
+		return fmt.Sprintf("ch%d", r.Channel)
+	case IdentityProtocolChannel:
+		return fmt.Sprintf("%s/ch%d", r.Protocol, r.Channel)
+	}
+	return r.SensorID
+}
+
+// pairingMaxAge is the time after which a sensor that hasn't been seen
+// anymore is forgotten by the pairings.
+const pairingMaxAge = 7 * 24 * time.Hour
+
+// pairingSaveInterval is how often changed pairings are persisted.
+const pairingSaveInterval = time.Minute
+
+// pairing is the last reading of a sensor by id.
+type pairing struct {
+	Protocol string    `json:"protocol"`
+	ID       string    `json:"id"`
+	Channel  int       `json:"channel"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Pairings keeps track of the channel and last reading of sensors, so a
+// location can be rebound to a new id appearing on the same channel once the
+// old id went silent. They are persisted to a JSON file, if one is given, so
+// a restart doesn't lose track of sensors. It is safe for concurrent use.
+type Pairings struct {
+	mu      sync.Mutex
+	path    string
+	sensors map[string]*pairing
+	dirty   bool       // changed since they were last saved
+	saveMu  sync.Mutex // serializes writing the file
+}
+
+// NewPairings creates Pairings that haven't seen any sensors yet.
+func NewPairings() *Pairings {
+	return &Pairings{
+		sensors: map[string]*pairing{},
+	}
+}
+
+// LoadPairings loads the pairings from the file at path, if it exists,
+// and persists them there. An empty path disables persisting them.
+func LoadPairings(path string) (*Pairings, error) {
+	p := NewPairings()
+	p.path = path
+	if path == "" {
+		return p, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read pairings file")
+	}
+	if err := json.Unmarshal(b, &p.sensors); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse pairings file '%s'", path)
+	}
+	return p, nil
+}
+
+// Seen records a reading. Only readings of sensors with a location need
+// to be recorded, as only their location can be rebound.
+func (p *Pairings) Seen(r *Reading) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := r.Protocol + ":" + r.SensorID
+	p.sensors[key] = &pairing{
+		Protocol: r.Protocol,
+		ID:       r.SensorID,
+		Channel:  r.Channel,
+		LastSeen: r.Time,
+	}
+	for key, s := range p.sensors {
+		if r.Time.Sub(s.LastSeen) > pairingMaxAge {
+			delete(p.sensors, key)
+		}
+	}
+	p.dirty = true
+}
+
+// Silent returns the ids of sensors of the same protocol and channel as the
+// reading, but with another id, that haven't been seen for longer than
+// silence, most recently seen first.
+func (p *Pairings) Silent(r *Reading, silence time.Duration) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var silent []*pairing
+	for _, s := range p.sensors {
+		if s.Protocol == r.Protocol && s.Channel == r.Channel && s.ID != r.SensorID &&
+			r.Time.Sub(s.LastSeen) > silence {
+			silent = append(silent, s)
+		}
+	}
+	sort.Slice(silent, func(i, j int) bool {
+		if !silent[i].LastSeen.Equal(silent[j].LastSeen) {
+			return silent[i].LastSeen.After(silent[j].LastSeen)
+		}
+		return silent[i].ID < silent[j].ID
+	})
+	ids := make([]string, len(silent))
+	for i, s := range silent {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// Forget removes a sensor.
+func (p *Pairings) Forget(protocol, id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.sensors, protocol+":"+id)
+	p.dirty = true
+}
+
+// Save writes the pairings to the file if they changed, logging failures,
+// as they only matter after a restart. Failed saves are retried by the next one.
+func (p *Pairings) Save() {
+	if p.path == "" {
+		return
+	}
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+
+	p.mu.Lock()
+	if !p.dirty {
+		p.mu.Unlock()
+		return
+	}
+	b, err := json.MarshalIndent(p.sensors, "", "  ")
+	p.dirty = false
+	p.mu.Unlock()
+
+	if err == nil {
+		err = writeFile(p.path, b)
+	}
+	if err != nil {
+		log.Println(errors.Wrap(err, "Failed to save pairings"))
+		p.mu.Lock()
+		p.dirty = true
+		p.mu.Unlock()
+	}
+}
+
+// Run saves changed pairings periodically.
+func (p *Pairings) Run() {
+	ticker := time.NewTicker(pairingSaveInterval)
+	for range ticker.C {
+		p.Save()
+	}
+}
+
+// repair rebinds the location of a silent sensor on the same channel to the
+// unmapped sensor of the reading, if the protocol allows it. It returns the
+// location the sensor was bound to.
+func repair(r *Reading, p *Protocol) string {
+	if p.RepairAfter <= 0 || (p.Identity != "" && p.Identity != IdentityID) {
+		return ""
+	}
+	for _, old := range pairings.Silent(r, p.RepairAfter) {
+		if locations.Location(old) == "" {
+			continue
+		}
+		if err := locations.Move(old, r.SensorID); err != nil {
+			log.Println(err)
+			continue
+		}
+		pairings.Forget(r.Protocol, old)
+		log.Printf("Sensor %s on channel %d replaces silent sensor %s\n", r.SensorID, r.Channel, old)
+		return locations.Location(r.SensorID)
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensorKey(t *testing.T) {
+	r := &Reading{Protocol: "protocol1", SensorID: "2454", Channel: 2}
+
+	assert.Equal(t, "2454", SensorKey(r, ""))
+	assert.Equal(t, "2454", SensorKey(r, IdentityID))
+	assert.Equal(t, "ch2", SensorKey(r, IdentityChannel))
+	assert.Equal(t, "protocol1/ch2", SensorKey(r, IdentityProtocolChannel))
+}
+
+func TestPairings_Silent(t *testing.T) {
+	p := NewPairings()
+	start := time.Now()
+
+	p.Seen(&Reading{Protocol: "protocol1", SensorID: "100", Channel: 2, Time: start})
+	p.Seen(&Reading{Protocol: "protocol1", SensorID: "101", Channel: 3, Time: start})
+	p.Seen(&Reading{Protocol: "grube", SensorID: "102", Channel: 2, Time: start})
+
+	r := &Reading{Protocol: "protocol1", SensorID: "200", Channel: 2, Time: start.Add(10 * time.Minute)}
+	assert.Empty(t, p.Silent(r, time.Hour), "not silent long enough")
+
+	r.Time = start.Add(2 * time.Hour)
+	assert.Equal(t, []string{"100"}, p.Silent(r, time.Hour))
+
+	p.Forget("protocol1", "100")
+	assert.Empty(t, p.Silent(r, time.Hour))
+}
+
+func TestPairings_persisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pairings")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pairings.json")
+
+	p, err := LoadPairings(path)
+	assert.NoError(t, err)
+	start := time.Now()
+	p.Seen(&Reading{Protocol: "protocol1", SensorID: "100", Channel: 2, Time: start})
+	p.Seen(&Reading{Protocol: "protocol1", SensorID: "101", Channel: 2, Time: start.Add(time.Minute)})
+	p.Seen(&Reading{Protocol: "protocol1", SensorID: "102", Channel: 2, Time: start})
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "saved periodically, not on every reading")
+
+	p.Save()
+	reloaded, err := LoadPairings(path)
+	assert.NoError(t, err)
+	r := &Reading{Protocol: "protocol1", SensorID: "200", Channel: 2, Time: start.Add(2 * time.Hour)}
+	assert.Equal(t, []string{"101", "100", "102"}, reloaded.Silent(r, time.Hour), "most recently seen first")
+}
+
+func TestPublishReading_repair(t *testing.T) {
+	defer func(l *Locations, r *Registry, p *Pairings, d *Discovery) {
+		locations, registry, pairings, discovery = l, r, p, d
+	}(locations, registry, pairings, discovery)
+	protocol := Protocols()["protocol1"]
+	protocol.RepairAfter = time.Hour
+	var err error
+	registry, err = NewRegistry(map[string]*Protocol{"protocol1": protocol})
+	assert.NoError(t, err)
+	locations, err = NewLocations("", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, locations.Configure("100", SensorConfig{
+		Location:    "kitchen",
+		Calibration: map[string]Calibration{FieldTemperature: {Offset: -1}},
+	}))
+	pairings = NewPairings()
+	discovery = NewDiscovery()
+	start := time.Now()
+	pairings.Seen(&Reading{Protocol: "protocol1", SensorID: "100", Channel: 2, Time: start})
+
+	r := &Reading{Protocol: "protocol1", SensorID: "101", Channel: 2, Time: start.Add(2 * time.Hour),
+		Measurements: []Measurement{{FieldTemperature, 21, UnitCelsius}}}
+	assert.True(t, PublishReading(r))
+	assert.Equal(t, "kitchen", r.Location)
+	temp, _ := r.Value(FieldTemperature)
+	assert.Equal(t, 20.0, temp, "calibrated like the replaced sensor")
+
+	assert.True(t, PublishReading(&Reading{Protocol: "protocol1", SensorID: "300", Channel: 3, Time: r.Time}))
+	assert.Contains(t, pairings.sensors, "protocol1:101")
+	assert.NotContains(t, pairings.sensors, "protocol1:300", "only sensors with a location are tracked")
+}
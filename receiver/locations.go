@@ -0,0 +1,482 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SensorConfig is the configuration of a sensor that is mapped to a location.
+type SensorConfig struct {
+	Location    string                 `json:"location"`
+	Calibration map[string]Calibration `json:"calibration,omitempty"` // by measurement, e.g. temperature
+	Tank        *Tank                  `json:"tank,omitempty"`        // geometry of the tank the distance of the sensor is measured in
+}
+
+// clone returns a deep copy of the configuration.
+func (c SensorConfig) clone() SensorConfig {
+	if c.Calibration != nil {
+		calibration := make(map[string]Calibration, len(c.Calibration))
+		for name, cal := range c.Calibration {
+			calibration[name] = cal
+		}
+		c.Calibration = calibration
+	}
+	if c.Tank != nil {
+		t := c.Tank.clone()
+		c.Tank = &t
+	}
+	return c
+}
+
+func (c *SensorConfig) validate() error {
+	if c.Tank != nil {
+		return c.Tank.validate()
+	}
+	return nil
+}
+
+// Locations maps sensor ids to their configuration, most importantly their
+// location. Changes are persisted to a JSON file, if one is given.
+// It is safe for concurrent use.
+type Locations struct {
+	mu       sync.RWMutex
+	path     string
+	sensors  map[string]SensorConfig
+	defaults map[string]SensorConfig // for sensors not in the file
+	removed  map[string]bool         // defaults removed at runtime
+
+	// OnChange is called after the location of a sensor was added, renamed
+	// or removed, with an empty old location for added sensors and an
+	// empty new location for removed ones.
+	OnChange func(id, oldLocation, newLocation string)
+}
+
+// NewLocations loads the sensor mapping from the file at path, if it exists,
+// and adds the initial mapping for all sensors the file doesn't contain.
+// An empty path disables persisting the mapping.
+func NewLocations(path string, initial map[string]string) (*Locations, error) {
+	sensors, removed, err := loadLocations(path)
+	if err != nil {
+		return nil, err
+	}
+	defaults := map[string]SensorConfig{}
+	for id, location := range initial {
+		defaults[id] = SensorConfig{Location: location}
+		if _, ok := sensors[id]; !ok && !removed[id] {
+			sensors[id] = defaults[id]
+		}
+	}
+	return &Locations{
+		path:     path,
+		sensors:  sensors,
+		defaults: defaults,
+		removed:  removed,
+	}, nil
+}
+
+// loadLocations reads and validates the sensor mapping from the file at path,
+// and the sensors that were removed although they have a default, which are
+// stored as null. A missing file or an empty path results in an empty mapping.
+func loadLocations(path string) (map[string]SensorConfig, map[string]bool, error) {
+	sensors := map[string]SensorConfig{}
+	removed := map[string]bool{}
+	if path == "" {
+		return sensors, removed, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sensors, removed, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to read locations file")
+	}
+	var file map[string]*SensorConfig
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to parse locations file '%s'", path)
+	}
+	for id, c := range file {
+		if c == nil {
+			removed[id] = true
+			continue
+		}
+		if err := c.validate(); err != nil {
+			return nil, nil, errors.Wrapf(err, "Invalid configuration of sensor '%s'", id)
+		}
+		sensors[id] = *c
+	}
+	return sensors, removed, nil
+}
+
+// Reload replaces the mapping with the content of the file, e.g. after it
+// was edited by hand, and the initial mapping of sensors it doesn't contain.
+// The mapping is left unchanged if the file is invalid.
+func (l *Locations) Reload() error {
+	if l.path == "" {
+		return nil
+	}
+	sensors, removed, err := loadLocations(l.path)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	for id, c := range l.defaults {
+		if _, ok := sensors[id]; !ok && !removed[id] {
+			sensors[id] = c
+		}
+	}
+	old := l.sensors
+	l.sensors = sensors
+	l.removed = removed
+	l.mu.Unlock()
+
+	if l.OnChange == nil {
+		return nil
+	}
+	for id, c := range old {
+		if sensors[id].Location != c.Location {
+			l.OnChange(id, c.Location, sensors[id].Location)
+		}
+	}
+	for id, c := range sensors {
+		if _, ok := old[id]; !ok {
+			l.OnChange(id, "", c.Location)
+		}
+	}
+	return nil
+}
+
+// Defaults sets the configuration of sensors that aren't in the file, e.g.
+// those of the config file. Sensors that still have their previous default
+// get the new one, or are removed if they don't have one anymore. Defaults
+// aren't persisted, so they are applied again after a restart.
+func (l *Locations) Defaults(sensors map[string]SensorConfig) error {
+	for id, c := range sensors {
+		if c.Location == "" {
+			return fmt.Errorf("Sensor '%s' has no location", id)
+		}
+		if err := c.validate(); err != nil {
+			return errors.Wrapf(err, "Invalid configuration of sensor '%s'", id)
+		}
+	}
+
+	var changes [][3]string
+	l.mu.Lock()
+	old := l.defaults
+	l.defaults = sensors
+	for id, c := range old {
+		if _, ok := sensors[id]; ok {
+			continue
+		}
+		if current, ok := l.sensors[id]; ok && reflect.DeepEqual(current, c) {
+			delete(l.sensors, id)
+			changes = append(changes, [3]string{id, c.Location, ""})
+		}
+	}
+	for id, c := range sensors {
+		current, ok := l.sensors[id]
+		if previous, wasDefault := old[id]; ok && (!wasDefault || !reflect.DeepEqual(current, previous)) {
+			continue // changed at runtime or in the file
+		}
+		if l.removed[id] {
+			continue
+		}
+		l.sensors[id] = c
+		if current.Location != c.Location {
+			changes = append(changes, [3]string{id, current.Location, c.Location})
+		}
+	}
+	l.mu.Unlock()
+
+	if l.OnChange != nil {
+		for _, c := range changes {
+			l.OnChange(c[0], c[1], c[2])
+		}
+	}
+	return nil
+}
+
+// isDefault reports whether the sensor has its default configuration.
+// The caller must hold the lock.
+func (l *Locations) isDefault(id string) bool {
+	c, ok := l.defaults[id]
+	return ok && reflect.DeepEqual(l.sensors[id], c)
+}
+
+// Location returns the location of the sensor, or an empty string if it has none.
+func (l *Locations) Location(id string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.sensors[id].Location
+}
+
+// List returns a copy of the mapping of all sensors.
+func (l *Locations) List() map[string]SensorConfig {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	list := make(map[string]SensorConfig, len(l.sensors))
+	for id, c := range l.sensors {
+		list[id] = c
+	}
+	return list
+}
+
+// Config returns the configuration of the sensor.
+func (l *Locations) Config(id string) (SensorConfig, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c, ok := l.sensors[id]
+	return c, ok
+}
+
+// Set adds a sensor or changes its location.
+func (l *Locations) Set(id, location string) error {
+	return l.Update(id, func(c *SensorConfig) error {
+		c.Location = location
+		return nil
+	})
+}
+
+// Configure adds a sensor or replaces its configuration.
+func (l *Locations) Configure(id string, c SensorConfig) error {
+	return l.Update(id, func(current *SensorConfig) error {
+		*current = c
+		return nil
+	})
+}
+
+// Update adds a sensor or changes its configuration in one step, so
+// concurrent changes of other fields aren't lost. change is called with
+// a copy of the current configuration, which is empty for new sensors.
+func (l *Locations) Update(id string, change func(c *SensorConfig) error) error {
+	if id == "" {
+		return fmt.Errorf("Sensor id must not be empty")
+	}
+
+	l.mu.Lock()
+	old := l.sensors[id].Location
+	c := l.sensors[id].clone()
+	err := change(&c)
+	if err == nil && c.Location == "" {
+		err = fmt.Errorf("Sensor location must not be empty")
+	}
+	if err == nil {
+		err = c.validate()
+	}
+	if err == nil {
+		err = l.commit(func() {
+			l.sensors[id] = c
+			delete(l.removed, id)
+		})
+	}
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if l.OnChange != nil && old != c.Location {
+		l.OnChange(id, old, c.Location)
+	}
+	return nil
+}
+
+// Remove removes a sensor from the mapping. A sensor that has a default,
+// e.g. from the config file, stays removed until it is added again.
+func (l *Locations) Remove(id string) error {
+	l.mu.Lock()
+	c, ok := l.sensors[id]
+	if !ok {
+		l.mu.Unlock()
+		return fmt.Errorf("Sensor '%s' has no location", id)
+	}
+	err := l.commit(func() {
+		l.forget(id)
+	})
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if l.OnChange != nil {
+		l.OnChange(id, c.Location, "")
+	}
+	return nil
+}
+
+// Move moves the configuration of a sensor to another key,
+// e.g. when a sensor has picked a new id.
+func (l *Locations) Move(from, to string) error {
+	l.mu.Lock()
+	c, ok := l.sensors[from]
+	if !ok {
+		l.mu.Unlock()
+		return fmt.Errorf("Sensor '%s' has no location", from)
+	}
+	if _, taken := l.sensors[to]; taken {
+		l.mu.Unlock()
+		return fmt.Errorf("Sensor '%s' already has a location", to)
+	}
+	err := l.commit(func() {
+		l.forget(from)
+		l.sensors[to] = c
+		delete(l.removed, to)
+	})
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if l.OnChange != nil {
+		l.OnChange(from, c.Location, "")
+		l.OnChange(to, "", c.Location)
+	}
+	return nil
+}
+
+// forget removes a sensor and keeps its default from adding it again.
+// The caller must hold the lock.
+func (l *Locations) forget(id string) {
+	delete(l.sensors, id)
+	if _, ok := l.defaults[id]; ok {
+		l.removed[id] = true
+	}
+}
+
+// SaveError is returned by changes of the mapping that couldn't be
+// persisted. Such changes are rolled back.
+type SaveError struct {
+	Err error
+}
+
+func (e *SaveError) Error() string {
+	return e.Err.Error()
+}
+
+// commit applies the change to the mapping and persists it. If it can't
+// be persisted, the change is rolled back. The caller must hold the lock.
+func (l *Locations) commit(change func()) error {
+	sensors := make(map[string]SensorConfig, len(l.sensors))
+	for id, c := range l.sensors {
+		sensors[id] = c
+	}
+	removed := make(map[string]bool, len(l.removed))
+	for id := range l.removed {
+		removed[id] = true
+	}
+
+	change()
+	if err := l.save(); err != nil {
+		l.sensors, l.removed = sensors, removed
+		return &SaveError{err}
+	}
+	return nil
+}
+
+// save writes the mapping to the file, replacing it atomically.
+// The caller must hold the lock.
+func (l *Locations) save() error {
+	if l.path == "" {
+		return nil
+	}
+	file := make(map[string]*SensorConfig, len(l.sensors)+len(l.removed))
+	for id := range l.removed {
+		file[id] = nil
+	}
+	for id := range l.sensors {
+		if l.isDefault(id) {
+			continue
+		}
+		c := l.sensors[id]
+		file[id] = &c
+	}
+	b, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(writeFile(l.path, b), "Failed to save locations")
+}
+
+// writeFile replaces the file at path with b atomically.
+func writeFile(path string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ServeHTTP implements the API for managing the mapping:
+//
+//	GET    /locations       lists all sensors
+//	GET    /locations/{id}  returns the configuration of a sensor
+//	PUT    /locations/{id}  changes the given fields of the configuration of a sensor,
+//	                        e.g. {"location": "kitchen"}, null removes a field
+//	DELETE /locations/{id}  removes a sensor
+func (l *Locations) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(req.URL.Path, "/locations"), "/")
+
+	switch {
+	case req.Method == http.MethodGet && id == "":
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(l.List()); err != nil {
+			log.Println(err)
+		}
+	case req.Method == http.MethodGet:
+		c, ok := l.Config(id)
+		if !ok {
+			http.Error(w, "Sensor not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			log.Println(err)
+		}
+	case req.Method == http.MethodPut && id != "":
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = l.Update(id, func(c *SensorConfig) error {
+			return json.Unmarshal(body, c)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+			return
+		}
+	case req.Method == http.MethodDelete && id != "":
+		if err := l.Remove(id); err != nil {
+			http.Error(w, err.Error(), errorStatus(err, http.StatusNotFound))
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// errorStatus returns the HTTP status of a failed change of the mapping:
+// an internal error if it couldn't be persisted, otherwise the given status.
+func errorStatus(err error, status int) int {
+	if _, ok := err.(*SaveError); ok {
+		return http.StatusInternalServerError
+	}
+	return status
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocations_persisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "locations")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "locations.json")
+
+	l, err := NewLocations(path, map[string]string{"2454": "kitchen"})
+	assert.NoError(t, err)
+
+	var changes [][3]string
+	l.OnChange = func(id, oldLocation, newLocation string) {
+		changes = append(changes, [3]string{id, oldLocation, newLocation})
+	}
+	assert.NoError(t, l.Set("2454", "cellar"))
+	assert.NoError(t, l.Set("100", "garden"))
+	assert.NoError(t, l.Remove("100"))
+	assert.Error(t, l.Remove("100"))
+	assert.Equal(t, [][3]string{
+		{"2454", "kitchen", "cellar"},
+		{"100", "", "garden"},
+		{"100", "garden", ""},
+	}, changes)
+
+	reloaded, err := NewLocations(path, map[string]string{"2454": "kitchen", "7": "attic"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]SensorConfig{
+		"2454": {Location: "cellar"},
+		"7":    {Location: "attic"},
+	}, reloaded.List())
+}
+
+func TestLocations_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "locations")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "locations.json")
+
+	l, err := NewLocations(path, map[string]string{"200": "Grube"})
+	assert.NoError(t, err)
+	assert.NoError(t, l.Set("2454", "kitchen"))
+
+	var changes [][3]string
+	l.OnChange = func(id, oldLocation, newLocation string) {
+		changes = append(changes, [3]string{id, oldLocation, newLocation})
+	}
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"2454": {"location": "kitchen", "calibration": {"temperature": {"offset": -1.2}}}
+	}`), 0644))
+	assert.NoError(t, l.Reload())
+	c, _ := l.Config("2454")
+	assert.Equal(t, -1.2, c.Calibration[FieldTemperature].Offset)
+	assert.Equal(t, "Grube", l.Location("200"), "initial mappings are kept")
+	assert.Empty(t, changes)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"2454": {"location": "kitchen", "tank": {"shape": "ball"}}}`), 0644))
+	assert.Error(t, l.Reload())
+	c, _ = l.Config("2454")
+	assert.NotNil(t, c.Calibration, "invalid files are ignored")
+}
+
+func TestLocations_removeDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "locations")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "locations.json")
+
+	l, err := NewLocations(path, map[string]string{"200": "Grube"})
+	assert.NoError(t, err)
+	assert.NoError(t, l.Remove("200"))
+
+	reloaded, err := NewLocations(path, map[string]string{"200": "Grube"})
+	assert.NoError(t, err)
+	assert.Empty(t, reloaded.List(), "removed defaults stay removed")
+	assert.NoError(t, reloaded.Reload())
+	assert.Empty(t, reloaded.List())
+
+	assert.NoError(t, reloaded.Set("200", "Zisterne"))
+	reloaded, err = NewLocations(path, map[string]string{"200": "Grube"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Zisterne", reloaded.Location("200"))
+}
+
+func TestLocations_saveFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "locations")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := NewLocations(filepath.Join(dir, "missing", "locations.json"), map[string]string{"200": "Grube"})
+	assert.NoError(t, err)
+	var changes int
+	l.OnChange = func(id, oldLocation, newLocation string) {
+		changes++
+	}
+
+	assert.IsType(t, &SaveError{}, l.Set("2454", "kitchen"))
+	assert.IsType(t, &SaveError{}, l.Remove("200"))
+	assert.Equal(t, map[string]SensorConfig{"200": {Location: "Grube"}}, l.List(), "changes are rolled back")
+	assert.Zero(t, changes)
+
+	w := httptest.NewRecorder()
+	l.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/locations/2454", strings.NewReader(`{"location": "kitchen"}`)))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	w = httptest.NewRecorder()
+	l.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/locations/7", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLocations_partialUpdate(t *testing.T) {
+	l, err := NewLocations("", nil)
+	assert.NoError(t, err)
+	put := func(body string) int {
+		w := httptest.NewRecorder()
+		l.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/locations/200", strings.NewReader(body)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, put(`{"location": "Grube", "tank": {"shape": "cylinder", "diameter": 1, "height": 2, "low": 10}}`))
+	assert.Equal(t, http.StatusOK, put(`{"calibration": {"distance": {"offset": 3}}}`))
+	assert.NoError(t, l.Set("200", "Zisterne"))
+	c, _ := l.Config("200")
+	assert.Equal(t, "Zisterne", c.Location)
+	assert.Equal(t, 3.0, c.Calibration[FieldDistance].Offset)
+	if assert.NotNil(t, c.Tank, "the tank is kept") {
+		assert.Equal(t, 10.0, *c.Tank.Low)
+	}
+
+	assert.Equal(t, http.StatusOK, put(`{"tank": {"low": 20}}`))
+	assert.Equal(t, 10.0, *c.Tank.Low, "returned configurations aren't changed")
+	assert.Equal(t, http.StatusBadRequest, put(`{"tank": {"shape": "ball"}}`))
+	assert.Equal(t, http.StatusOK, put(`{"tank": null}`))
+	c, _ = l.Config("200")
+	assert.Nil(t, c.Tank)
+	assert.Equal(t, http.StatusBadRequest, put(`{"location": ""}`))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			put(`{"tank": {"shape": "cylinder", "diameter": 1, "height": 2}}`)
+		}()
+		go func() {
+			defer wg.Done()
+			l.Set("200", "Grube")
+		}()
+	}
+	wg.Wait()
+	c, _ = l.Config("200")
+	assert.Equal(t, "Grube", c.Location)
+	assert.NotNil(t, c.Tank, "concurrent changes of other fields aren't lost")
+}
+
+func TestLocations_Defaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "locations")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "locations.json")
+
+	l, err := NewLocations(path, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Defaults(map[string]SensorConfig{
+		"200":  {Location: "Grube"},
+		"2454": {Location: "kitchen"},
+		"7":    {Location: "attic"},
+	}))
+	assert.NoError(t, l.Set("7", "cellar"))
+
+	var changes [][3]string
+	l.OnChange = func(id, oldLocation, newLocation string) {
+		changes = append(changes, [3]string{id, oldLocation, newLocation})
+	}
+	assert.NoError(t, l.Defaults(map[string]SensorConfig{
+		"200": {Location: "Zisterne"},
+		"7":   {Location: "garage"},
+	}))
+	assert.Equal(t, map[string]SensorConfig{
+		"200": {Location: "Zisterne"},
+		"7":   {Location: "cellar"},
+	}, l.List(), "changes at runtime are kept")
+	assert.ElementsMatch(t, [][3]string{
+		{"2454", "kitchen", ""},
+		{"200", "Grube", "Zisterne"},
+	}, changes)
+
+	reloaded, err := NewLocations(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]SensorConfig{"7": {Location: "cellar"}}, reloaded.List(), "defaults aren't persisted")
+}
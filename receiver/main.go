@@ -4,8 +4,15 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/panzerdev/433mhz-receiver/receiver/admin"
 	"github.com/panzerdev/grpc-impl/sensors/sensor"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -15,27 +22,44 @@ import (
 )
 
 var (
+	configFile = kingpin.Flag("config", "YAML file with the configuration, whose settings take precedence over the flags. It is reloaded on SIGHUP and when it changes.").String()
+	input      = kingpin.Flag("input", "Where raw signals are read from: serial, tcp://host:port, tcp-listen://:port, stdin or file://path.").
+			Default("serial").String()
 	device = kingpin.Flag("device", "Arduino connected to USB").
 		Default("/dev/ttyUSB0").String()
-	listenAddr = kingpin.Flag("listen-address", "The address to listen on for HTTP requests.").
+	usbDevice   = kingpin.Flag("usb", "USB vendor and product id and optionally the serial number of the Arduino, e.g. 1a86:7523, used instead of --device.").String()
+	baudRate    = kingpin.Flag("baud-rate", "Baud rate of the serial port.").Default(strconv.Itoa(DefaultBaudRate)).Int()
+	readTimeout = kingpin.Flag("read-timeout", "Time after which the input is reopened if nothing was read from it, 0 disables it.").Duration()
+	listenAddr  = kingpin.Flag("listen-address", "The address to listen on for HTTP requests.").
 			Default(":8080").String()
 
-	grpcListenAddr = kingpin.Flag("grpc-listen-address", "The address to listen on for HTTP requests.").
+	grpcListenAddr = kingpin.Flag("grpc-listen-address", "The address to listen on for gRPC requests.").
 			Default(":8082").String()
-	ids       = kingpin.Arg("ids", "Sensor IDs that will be exported").StringMap()
-	redisAddr = kingpin.Flag("redis", "Sensor IDs that will be exported").Default("192.168.2.22:6379").String()
-
-	temperature     *prometheus.GaugeVec
-	humidity        *prometheus.GaugeVec
-	locationCount   *prometheus.CounterVec
-	distance        prometheus.Gauge
-	sensorLocations map[string]string
-	srv             *Server
-)
-
-const (
-	SensorID       = "id"
-	SensorLocation = "location"
+	pushPort     = kingpin.Flag("push-port", "The port to listen on for registrations of devices that receive pushes.").Default("8081").String()
+	protocolFile = kingpin.Flag("protocols", "YAML or JSON file with additional protocol definitions.").String()
+	locationFile = kingpin.Flag("locations", "JSON file the sensor to location mapping is persisted to. The channels of the sensors used for re-pairing are persisted next to it in pairings.json.").String()
+	ids          = sensorIDs(kingpin.CommandLine)
+	redisAddr    = kingpin.Flag("redis", "Address of the Redis server the push registrations are stored in.").Default("192.168.2.22:6379").String()
+
+	maxRates   = kingpin.Flag("max-rate", "Maximum change per minute of a measurement, e.g. temperature=5, can be repeated.").StringMap()
+	staleAfter = kingpin.Flag("stale-after", "Time after which a sensor that stopped reporting is considered stale, 0 disables it.").
+			Default("1h").Duration()
+	recordingFile = kingpin.Flag("recording", "JSON lines file raw signals are recorded to while recording is enabled via /recording.").
+			Default("recording.jsonl").String()
+	sinks = kingpin.Flag("sink", "Sink that consumes decoded readings and events, can be repeated.").
+		Default(SinkMetrics, SinkPush).Enums(SinkMetrics, SinkPush)
+
+	config       *Config
+	locations    *Locations
+	registry     *Registry
+	events       = NewBus()
+	dedup        = NewDeduplicator()
+	voter        = NewVoter()
+	spikeFilter  = NewSpikeFilter(DefaultMaxRates)
+	discovery    = NewDiscovery()
+	pairings     = NewPairings()
+	staleWatcher *StaleWatcher
+	recorder     = NewRecorder("", 0, 0)
 )
 
 type SensorServer struct {
@@ -49,87 +73,182 @@ func (s *SensorServer) StreamReadings(stream sensor.SensorReportingService_Strea
 			return err
 		}
 
-		if !ValidateTempHumid(float64(data.Dht22.Temperature), int(data.Dht22.Humidity)) {
-			log.Printf("Sensor has unreasonable data %+v\n", data)
-			continue
-		}
+		PublishReading(&Reading{
+			Protocol: "grpc",
+			SensorID: data.Id,
+			Location: data.Location,
+			Time:     time.Now(),
+			Measurements: []Measurement{
+				{FieldTemperature, float64(data.Dht22.Temperature), UnitCelsius},
+				{FieldHumidity, float64(data.Dht22.Humidity), UnitPercent},
+			},
+		})
+	}
+}
 
-		temperature.With(prometheus.Labels{
-			SensorID:       data.Id,
-			SensorLocation: data.Location,
-		}).Set(float64(data.Dht22.Temperature))
+// flagConfig returns the configuration given by the flags.
+// sensorIDs declares the argument with the sensors and their locations.
+func sensorIDs(app *kingpin.Application) *map[string]string {
+	return app.Arg("ids", "Sensor IDs and their locations, e.g. 2454=kitchen, for sensors not in the locations file. Without any, the pit sensor is mapped to Grube.").
+		Default("200=Grube").StringMap()
+}
 
-		humidity.With(prometheus.Labels{
-			SensorID:       data.Id,
-			SensorLocation: data.Location,
-		}).Set(float64(data.Dht22.Humidity))
+// sensorConfigs configures the sensors given by their ids and locations.
+func sensorConfigs(ids map[string]string) map[string]SensorConfig {
+	sensors := map[string]SensorConfig{}
+	for id, location := range ids {
+		sensors[id] = SensorConfig{Location: location}
+	}
+	return sensors
+}
 
-		locationCount.With(prometheus.Labels{
-			SensorID:       data.Id,
-			SensorLocation: data.Location,
-		}).Inc()
+func flagConfig() Config {
+	serial := SerialConfig{
+		Device:   *device,
+		BaudRate: *baudRate,
+	}
+	if *usbDevice != "" {
+		ids := strings.SplitN(*usbDevice, ":", 3)
+		serial.Device = ""
+		serial.VID = ids[0]
+		if len(ids) > 1 {
+			serial.PID = ids[1]
+		}
+		if len(ids) > 2 {
+			serial.SerialNumber = ids[2]
+		}
+	}
+	return Config{
+		Input:             *input,
+		Serial:            serial,
+		ReadTimeout:       *readTimeout,
+		ListenAddress:     *listenAddr,
+		GRPCListenAddress: *grpcListenAddr,
+		Redis:             *redisAddr,
+		PushPort:          *pushPort,
+		Sinks:             *sinks,
+		StaleAfter:        *staleAfter,
+		MaxRates:          *maxRates,
+		LocationFile:      *locationFile,
+		Sensors:           sensorConfigs(*ids),
+		ProtocolFile:      *protocolFile,
+		Recording:         RecordingConfig{Path: *recordingFile},
 	}
 }
 
 func main() {
 	kingpin.Parse()
 
-	sensorLocations = *ids
-
-	temperature = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "meter_temperature_celsius",
-		Help: "Current temperature in Celsius",
-	}, []string{
-		SensorID,
-		SensorLocation,
-	})
-	humidity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "meter_humidity_percent",
-		Help: "Current humidity level in %",
-	}, []string{
-		SensorID,
-		SensorLocation,
-	})
-
-	locationCount = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "count_location_reporting",
-		Help: "Number of records",
-	}, []string{
-		SensorID,
-		SensorLocation,
-	})
-
-	distance = prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "meter_distance_to_water",
-		Help: "Distance to water",
-	})
-
-	prometheus.MustRegister(temperature)
-	prometheus.MustRegister(humidity)
-	prometheus.MustRegister(locationCount)
-	prometheus.MustRegister(distance)
+	c, err := LoadConfig(*configFile, flagConfig())
+	if err != nil {
+		log.Fatalln(err)
+	}
+	config = c
 
-	http.Handle("/metrics", promhttp.Handler())
+	l, err := NewLocations(config.LocationFile, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := l.Defaults(config.Sensors); err != nil {
+		log.Fatalln(err)
+	}
+	// the pairings are kept next to the locations they rebind
+	if config.LocationFile != "" {
+		p, err := LoadPairings(filepath.Join(filepath.Dir(config.LocationFile), "pairings.json"))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		pairings = p
+		go pairings.Run()
+		defer pairings.Save()
+	}
+	l.OnChange = func(id, oldLocation, newLocation string) {
+		log.Printf("Sensor %s moved from '%s' to '%s'\n", id, oldLocation, newLocation)
+		if oldLocation != "" {
+			DeleteSensorMetrics(id, oldLocation)
+			if staleWatcher != nil {
+				staleWatcher.Forget(id, oldLocation)
+			}
+		}
+		discovery.Forget(id)
+	}
+	locations = l
 
-	server, err := NewPushServer("8081", *redisAddr)
+	protocols, err := config.protocols()
 	if err != nil {
 		log.Fatalln(err)
 	}
-	srv = server
+	r, err := NewRegistry(protocols)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	registry = r
 
-	dev, err := OpenDevice(*device)
+	rates, err := ParseMaxRates(config.MaxRates)
 	if err != nil {
-		log.Fatalf("Could not open '%v'", *device)
+		log.Fatalln(err)
+	}
+	spikeFilter.SetMaxRates(rates)
+	go watchConfig()
+
+	recorder = NewRecorder(config.Recording.Path, config.Recording.MaxSize, config.Recording.MaxFiles)
+	if err := recorder.Enable(config.Recording.Enabled); err != nil {
+		log.Fatalln(err)
+	}
+	defer recorder.Enable(false)
+
+	registerMetrics()
+	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/sensors/unmapped", discovery)
+	http.Handle("/locations", locations)
+	http.Handle("/locations/", locations)
+
+	events.Attach("battery", 100, NewBatteryWatcher(events))
+	tanks := NewTankWatcher(events, locations)
+	events.Attach("tanks", 100, tanks)
+	http.Handle("/tanks", tanks)
+	http.Handle("/recording", recorder)
+	if config.StaleAfter > 0 {
+		staleWatcher = NewStaleWatcher(config.StaleAfter, events)
+		events.Attach("stale", 100, staleWatcher)
+		go staleWatcher.Run()
+	}
+
+	for _, sink := range config.Sinks {
+		switch sink {
+		case SinkMetrics:
+			events.Attach(SinkMetrics, 100, MetricsSink{})
+		case SinkPush:
+			server, err := NewPushServer(config.PushPort, config.Redis)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			events.Attach(SinkPush, 10, PushSink{server})
+		}
+	}
+
+	var receivers Receivers
+	for _, c := range config.receivers() {
+		// receivers are opened in the background, so one that isn't
+		// available doesn't keep the others from receiving
+		dev, err := OpenInput(c)
+		if err != nil {
+			log.Printf("Skipping receiver '%s': %v\n", c.Name, err)
+			continue
+		}
+		receivers = append(receivers, dev)
 	}
-	defer dev.Close()
+	defer receivers.Close()
+	http.Handle("/health", receivers)
 
-	go receive(dev)
+	go receive(receivers.Lines())
 
 	gServer := grpc.NewServer()
 	sensor.RegisterSensorReportingServiceServer(gServer, &SensorServer{})
+	admin.RegisterSensorAdminServer(gServer, &AdminServer{})
 	reflection.Register(gServer)
 
-	listener, err := net.Listen("tcp", *grpcListenAddr)
+	listener, err := net.Listen("tcp", config.GRPCListenAddress)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -139,89 +258,239 @@ func main() {
 		log.Fatalln(gServer.Serve(listener))
 	}()
 
-	log.Printf("Serving metrics at '%v/metrics'", *listenAddr)
-	log.Fatal(http.ListenAndServe(*listenAddr, nil))
+	log.Printf("Serving metrics at '%v/metrics'", config.ListenAddress)
+	log.Fatal(http.ListenAndServe(config.ListenAddress, nil))
+}
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = 10 * time.Second
+
+// watchConfig reloads the configuration whenever the process receives
+// SIGHUP or the config file changes.
+func watchConfig() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	modTime := configModTime()
+	for {
+		select {
+		case <-hup:
+		case <-ticker.C:
+			t := configModTime()
+			if t.Equal(modTime) {
+				continue
+			}
+			modTime = t
+		}
+		reloadConfig()
+	}
 }
 
-func receive(a *Device) {
-	for line := range a.readChan {
+// configModTime returns the time the config file was last modified.
+func configModTime() time.Time {
+	if *configFile == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(*configFile)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadConfig applies the settings of the config file that can be changed
+// while running, along with the locations file. An invalid configuration is
+// reported and ignored.
+func reloadConfig() {
+	c, err := LoadConfig(*configFile, flagConfig())
+	if err != nil {
+		log.Printf("Not reloading the configuration: %v\n", err)
+		return
+	}
+	for _, name := range config.restartRequired(c) {
+		log.Printf("Changing %s requires a restart\n", name)
+	}
+
+	if err := locations.Reload(); err != nil {
+		log.Println(err)
+	}
+	if err := locations.Defaults(c.Sensors); err != nil {
+		log.Println(err)
+	}
+	if protocols, err := c.protocols(); err != nil {
+		log.Println(err)
+	} else if err := registry.Replace(protocols); err != nil {
+		log.Println(err)
+	}
+	if rates, err := ParseMaxRates(c.MaxRates); err != nil {
+		log.Println(err)
+	} else {
+		spikeFilter.SetMaxRates(rates)
+	}
+	if c.Recording.Enabled != config.Recording.Enabled {
+		if err := recorder.Enable(c.Recording.Enabled); err != nil {
+			log.Println(err)
+		}
+		config.Recording.Enabled = c.Recording.Enabled
+	}
+	log.Println("Reloaded the configuration")
+}
+
+func receive(lines <-chan Line) {
+	for line := range lines {
 		DecodeSignal(line)
 	}
+	log.Println("Stopped reading from all receivers")
 }
 
 // Process decodes a compressed signal read from the Arduino
-// by trying all currently supported protocols.
-func DecodeSignal(line string) {
-	trimmed := strings.TrimPrefix(line, ReceivePrefix)
+// by trying all currently supported protocols. The same frame
+// heard by several receivers is only processed once. The line
+// and the outcome are passed to the recorder.
+func DecodeSignal(line Line) {
+	rec := Record{Time: line.Time, Receiver: line.Receiver, Line: line.Text}
+	defer func() {
+		recorder.Record(rec)
+	}()
+	trimmed := strings.TrimPrefix(line.Text, ReceivePrefix)
 
 	p, err := PreparePulse(trimmed)
 	if err != nil {
 		log.Println(err)
+		rec.Outcome, rec.Error = OutcomeInvalid, err.Error()
 		return
 	}
 
-	device, result, err := DecodePulse(p)
+	match, err := registry.DecodePulse(p)
+	if b := match.Best(); b != nil {
+		rec.Protocol, rec.Binary = b.Protocol.Name, b.Binary
+		signalsMatched.With(prometheus.Labels{
+			ProtocolName: b.Protocol.Name,
+		}).Inc()
+		if match.Ambiguous() {
+			log.Printf("Ambiguous match with confidence %.3f: %v\n", match.Confidence(), match)
+			ambiguousMatches.With(prometheus.Labels{
+				ProtocolName: b.Protocol.Name,
+			}).Inc()
+		}
+	}
 	if err != nil {
+		rec.Outcome, rec.Error = OutcomeError, err.Error()
+		if _, ok := err.(*ChecksumError); ok {
+			rec.Outcome = OutcomeChecksum
+			framesChecksumRejected.With(prometheus.Labels{
+				ProtocolName: match.Best().Protocol.Name,
+			}).Inc()
+		}
 		log.Println(err)
 		return
 	}
-
-	switch device {
-	case GT_WT_01:
-		m := result.(*GTWT01Result)
-		log.Printf("%v: %+v\n", device, *m)
-		if loc, ok := sensorLocations[m.Name]; !ok || loc == "" {
-			log.Println("Sensor hasn't set a location and won't be provided to Prometheus for monitoring")
+	best := match.Best()
+	if best == nil {
+		log.Println("No protocol matched the signal")
+		rec.Outcome = OutcomeUnknown
+		return
+	}
+	if v := best.Protocol.Vote; v != nil {
+		bits, ok := voter.Add(line.Receiver, best.Protocol.Name, best.Protocol.identityBits(best.Binary), best.Binary, v, line.Time)
+		if !ok {
+			rec.Outcome = OutcomeVoting
 			return
 		}
-
-		if !m.ReasonableData() {
-			log.Printf("Sensor has unreasonable data %+v\n", m)
-			return
+		if bits != best.Binary {
+			best.Binary = bits
+			best.Result, err = best.Protocol.Decode(bits)
+			if err != nil {
+				log.Println(err)
+				rec.Outcome, rec.Error = OutcomeError, err.Error()
+				return
+			}
 		}
+	}
 
-		temperature.With(prometheus.Labels{
-			SensorID:       m.Name,
-			SensorLocation: sensorLocations[m.Name],
-		}).Set(m.Temperature)
-
-		humidity.With(prometheus.Labels{
-			SensorID:       m.Name,
-			SensorLocation: sensorLocations[m.Name],
-		}).Set(float64(m.Humidity))
-
-		locationCount.With(prometheus.Labels{
-			SensorID:       m.Name,
-			SensorLocation: sensorLocations[m.Name],
+	r := best.Result
+	r.Receiver = line.Receiver
+	if r.Time.IsZero() {
+		r.Time = line.Time
+	}
+	// only mapped sensors are counted, corrupted ids would add series forever
+	if key := SensorKey(r, best.Protocol.Identity); locations.Location(key) != "" {
+		receptions.With(prometheus.Labels{
+			ReceiverName: line.Receiver,
+			ProtocolName: best.Protocol.Name,
+			SensorID:     key,
 		}).Inc()
+	}
 
-	case DoorBell:
-		log.Println("The door is ringing!")
-		srv.SendPushes("no")
-	case DoorBellOld:
-		log.Println("The OLD Bell is ringing!")
-		srv.SendPushes("no")
-	case Grube:
-		m := result.(*GrubeData)
-		temperature.With(prometheus.Labels{
-			SensorID:       m.ID,
-			SensorLocation: m.Name,
-		}).Set(m.temp)
-
-		humidity.With(prometheus.Labels{
-			SensorID:       m.ID,
-			SensorLocation: m.Name,
-		}).Set(float64(m.humidity))
-
-		locationCount.With(prometheus.Labels{
-			SensorID:       m.ID,
-			SensorLocation: m.Name,
+	frame := best.Protocol.Name + ":" + best.Binary
+	rec.Binary, rec.Reading = best.Binary, r
+	if dedup.Echo(frame, line.Receiver, echoWindow, line.Time) {
+		rec.Outcome = OutcomeDuplicate
+		framesSuppressed.With(prometheus.Labels{
+			ProtocolName: best.Protocol.Name,
+		}).Inc()
+		return
+	}
+	if w := best.Protocol.dedupWindow(); w > 0 && dedup.Duplicate(frame, w, line.Time) {
+		rec.Outcome = OutcomeDuplicate
+		framesSuppressed.With(prometheus.Labels{
+			ProtocolName: best.Protocol.Name,
 		}).Inc()
+		return
+	}
 
-		distance.Set(float64(m.dist))
-		log.Printf("%+v\n", m)
-	default:
-		log.Println("Device", device)
+	if best.Protocol.Button {
+		log.Printf("%v was pressed!\n", best.Protocol.Device)
+		rec.Outcome = OutcomeButton
+		events.Publish(Event{Kind: ButtonEvent, Reading: r})
+		return
 	}
-	return
+
+	log.Printf("%v: %v\n", r.Protocol, r)
+	rec.Outcome = OutcomeReading
+	if !PublishReading(r) {
+		rec.Outcome = OutcomeRejected
+	}
+}
+
+// PublishReading calibrates a reading, assigns the location of the sensor to it,
+// adds the measurements derived from it and publishes it on the bus unless it
+// is rejected by the spike filter. Readings of sensors
+// without a location are recorded by the discovery. It returns whether the
+// reading was published.
+func PublishReading(r *Reading) bool {
+	var identity string
+	p, ok := registry.Lookup(r.Protocol)
+	if ok {
+		identity = p.Identity
+	}
+	r.Key = SensorKey(r, identity)
+
+	c, _ := locations.Config(r.Key)
+	if r.Location == "" {
+		r.Location = c.Location
+	}
+	if r.Location == "" && ok {
+		r.Location = repair(r, p)
+		c, _ = locations.Config(r.Key)
+	}
+	// the sensor may have taken over the calibration of the one it replaced
+	calibrate(r, c.Calibration)
+	if !spikeFilter.Accept(r) {
+		return false
+	}
+
+	if r.Location == "" {
+		discovery.Seen(r)
+	}
+	if c.Location != "" {
+		pairings.Seen(r)
+	}
+
+	applyTank(r, c.Tank)
+	applyClimate(r)
+	events.Publish(Event{Kind: ReadingEvent, Reading: r})
+	return true
 }
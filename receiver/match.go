@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// mappingFailedPenalty scales the score of candidates whose
+	// mapping or decoding failed, so they rank below all successful ones.
+	mappingFailedPenalty = 0.5
+	// ambiguityMargin is the confidence below which a match is
+	// considered ambiguous.
+	ambiguityMargin = 0.1
+)
+
+// Candidate is a protocol that matches a received signal.
+type Candidate struct {
+	Protocol *Protocol
+	Score    float64  // 0 to 1, higher is better
+	Binary   string   // binary representation of the pulse sequence
+	Result   *Reading // decoded reading
+	Err      error    // error of mapping or decoding the signal
+}
+
+func (c *Candidate) String() string {
+	if c.Err != nil {
+		return fmt.Sprintf("%s(%.3f, %v)", c.Protocol.Name, c.Score, c.Err)
+	}
+	return fmt.Sprintf("%s(%.3f)", c.Protocol.Name, c.Score)
+}
+
+// Match is the outcome of matching a signal against all registered protocols.
+type Match struct {
+	Candidates []*Candidate // matching protocols ranked by score, best first
+}
+
+// Best returns the best candidate, or nil if no protocol matched.
+func (m *Match) Best() *Candidate {
+	if len(m.Candidates) == 0 {
+		return nil
+	}
+	return m.Candidates[0]
+}
+
+// Device returns the device type of the best candidate.
+func (m *Match) Device() DeviceType {
+	if b := m.Best(); b != nil {
+		return b.Protocol.Type
+	}
+	return Unknown
+}
+
+// Confidence is the lead of the best candidate over the runner-up,
+// or the score of the best candidate if it is the only one.
+func (m *Match) Confidence() float64 {
+	switch len(m.Candidates) {
+	case 0:
+		return 0
+	case 1:
+		return m.Candidates[0].Score
+	}
+	return m.Candidates[0].Score - m.Candidates[1].Score
+}
+
+// Ambiguous reports whether several protocols matched
+// with nearly the same score.
+func (m *Match) Ambiguous() bool {
+	return len(m.Candidates) > 1 && m.Confidence() < ambiguityMargin
+}
+
+func (m *Match) String() string {
+	c := make([]string, len(m.Candidates))
+	for i, candidate := range m.Candidates {
+		c[i] = candidate.String()
+	}
+	return strings.Join(c, ", ")
+}
+
+// DecodePulse tries to decode a received Signal based on all protocols
+// of the registry. Every matching protocol is scored by its pulse length
+// deviation and whether its mapping and decoding succeed. The best one wins;
+// ties are broken by protocol name so the result is deterministic.
+func (r *Registry) DecodePulse(s *Signal) (*Match, error) {
+	m := &Match{}
+	for _, p := range r.List() {
+		sc, ok := score(s, p)
+		if !ok {
+			continue
+		}
+		c := &Candidate{
+			Protocol: p,
+			Score:    sc,
+		}
+		c.Binary, c.Err = convert(s.Seq, p.Mapping)
+		if c.Err == nil {
+			c.Result, c.Err = p.Decode(c.Binary)
+		}
+		if c.Err != nil {
+			c.Score *= mappingFailedPenalty
+		}
+		m.Candidates = append(m.Candidates, c)
+	}
+
+	// List is sorted by name, a stable sort keeps that order for equal scores
+	sort.SliceStable(m.Candidates, func(i, j int) bool {
+		return m.Candidates[i].Score > m.Candidates[j].Score
+	})
+
+	if b := m.Best(); b != nil && b.Err != nil {
+		return m, b.Err
+	}
+	return m, nil
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"log"
+	"reflect"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	SensorID        = "id"
+	SensorLocation  = "location"
+	ProtocolName    = "protocol"
+	MeasurementName = "measurement"
+	MeasurementUnit = "unit"
+	SinkName        = "sink"
+	DeviceName      = "device"
+	ReceiverName    = "receiver"
+)
+
+var (
+	temperature = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meter_temperature_celsius",
+		Help: "Current temperature in Celsius",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	humidity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meter_humidity_percent",
+		Help: "Current humidity level in %",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	locationCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_location_reporting",
+		Help: "Number of records",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	lastSeen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sensor_last_seen_timestamp_seconds",
+		Help: "Time the sensor last reported, in seconds since the epoch",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	lowBattery = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sensor_low_battery",
+		Help: "Whether the sensor reports a low battery (1) or not (0)",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	distance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meter_distance_to_water",
+		Help: "Distance from the sensor to the water level",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	dewPoint = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meter_dew_point_celsius",
+		Help: "Current dew point in Celsius",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	absoluteHumidity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meter_absolute_humidity_grams_per_cubic_meter",
+		Help: "Current water vapour in the air in g/m³",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	heatIndex = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meter_heat_index_celsius",
+		Help: "Current temperature as perceived by humans in Celsius",
+	}, []string{
+		SensorID,
+		SensorLocation,
+	})
+
+	sensorValue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meter_sensor_value",
+		Help: "Current value of measurements without a dedicated metric",
+	}, []string{
+		SensorID,
+		SensorLocation,
+		MeasurementName,
+		MeasurementUnit,
+	})
+
+	signalsMatched = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_signals_matched",
+		Help: "Number of received signals matched by a protocol",
+	}, []string{
+		ProtocolName,
+	})
+
+	ambiguousMatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_signals_ambiguous",
+		Help: "Number of received signals matched by several protocols with nearly the same score",
+	}, []string{
+		ProtocolName,
+	})
+
+	framesChecksumRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_frames_checksum_rejected",
+		Help: "Number of frames rejected because their checksum didn't match",
+	}, []string{
+		ProtocolName,
+	})
+
+	receptions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_receptions",
+		Help: "Number of frames of a mapped sensor received by a receiver, including those heard by other receivers as well",
+	}, []string{
+		ReceiverName,
+		ProtocolName,
+		SensorID,
+	})
+
+	framesSuppressed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_frames_suppressed",
+		Help: "Number of repeated frames suppressed as duplicates",
+	}, []string{
+		ProtocolName,
+	})
+
+	burstsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_bursts_rejected",
+		Help: "Number of bursts of repeated frames that ended without enough frames agreeing",
+	}, []string{
+		ProtocolName,
+	})
+
+	readingsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_readings_rejected",
+		Help: "Number of readings rejected because a measurement changed too fast",
+	}, []string{
+		ProtocolName,
+		MeasurementName,
+	})
+
+	deviceConnected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "device_connected",
+		Help: "Whether the receiver is connected (1) or not (0)",
+	}, []string{
+		DeviceName,
+	})
+
+	deviceReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_device_reconnects",
+		Help: "Number of times the receiver was reopened after the connection was lost",
+	}, []string{
+		DeviceName,
+	})
+
+	eventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_events_dropped",
+		Help: "Number of events dropped because a sink couldn't keep up",
+	}, []string{
+		SinkName,
+	})
+)
+
+func registerMetrics() {
+	prometheus.MustRegister(temperature)
+	prometheus.MustRegister(humidity)
+	prometheus.MustRegister(locationCount)
+	prometheus.MustRegister(lastSeen)
+	prometheus.MustRegister(lowBattery)
+	prometheus.MustRegister(distance)
+	prometheus.MustRegister(dewPoint)
+	prometheus.MustRegister(absoluteHumidity)
+	prometheus.MustRegister(heatIndex)
+	prometheus.MustRegister(sensorValue)
+	prometheus.MustRegister(signalsMatched)
+	prometheus.MustRegister(ambiguousMatches)
+	prometheus.MustRegister(framesChecksumRejected)
+	prometheus.MustRegister(receptions)
+	prometheus.MustRegister(framesSuppressed)
+	prometheus.MustRegister(burstsRejected)
+	prometheus.MustRegister(readingsRejected)
+	prometheus.MustRegister(deviceConnected)
+	prometheus.MustRegister(deviceReconnects)
+	prometheus.MustRegister(eventsDropped)
+}
+
+// SinkMetrics is the name of the sink exporting readings to Prometheus.
+const SinkMetrics = "metrics"
+
+// MetricsSink provides the measurements of readings to Prometheus.
+type MetricsSink struct{}
+
+// Handle exports the measurements of reading events.
+func (MetricsSink) Handle(e Event) {
+	if e.Kind == ReadingEvent {
+		ExportReading(e.Reading)
+	}
+}
+
+// ExportReading provides the measurements of a reading to Prometheus,
+// if the sensor has a location. Series are labeled with the key
+// of the sensor, which is its id unless its protocol maps it by channel.
+func ExportReading(r *Reading) {
+	if len(r.Measurements) == 0 {
+		return
+	}
+
+	location := r.Location
+	if location == "" {
+		return
+	}
+
+	if !r.Reasonable() {
+		log.Printf("Sensor has unreasonable data %v\n", r)
+		return
+	}
+
+	labels := prometheus.Labels{
+		SensorID:       r.Key,
+		SensorLocation: location,
+	}
+	for _, m := range r.Measurements {
+		switch m.Name {
+		case FieldTemperature:
+			temperature.With(labels).Set(m.Value)
+		case FieldHumidity:
+			humidity.With(labels).Set(m.Value)
+		case FieldDistance:
+			distance.With(labels).Set(m.Value)
+		case FieldDewPoint:
+			dewPoint.With(labels).Set(m.Value)
+		case FieldAbsoluteHumidity:
+			absoluteHumidity.With(labels).Set(m.Value)
+		case FieldHeatIndex:
+			heatIndex.With(labels).Set(m.Value)
+		default:
+			sensorValue.With(sensorValueLabels.add(prometheus.Labels{
+				SensorID:        r.Key,
+				SensorLocation:  location,
+				MeasurementName: m.Name,
+				MeasurementUnit: m.Unit,
+			})).Set(m.Value)
+		}
+	}
+	locationCount.With(labels).Inc()
+	lastSeen.With(labels).Set(float64(r.Time.Unix()))
+	switch {
+	case !r.HasBattery:
+		// sensors without a battery state would always look fine
+	case r.LowBattery:
+		lowBattery.With(labels).Set(1)
+	default:
+		lowBattery.With(labels).Set(0)
+	}
+}
+
+// DeleteSensorMetrics removes all series of a sensor at the given location.
+func DeleteSensorMetrics(id, location string) {
+	DeleteSensorValues(id, location)
+	labels := prometheus.Labels{
+		SensorID:       id,
+		SensorLocation: location,
+	}
+	locationCount.Delete(labels)
+	lastSeen.Delete(labels)
+	lowBattery.Delete(labels)
+}
+
+// DeleteSensorValues removes the series of the measurements of a sensor at
+// the given location, so outdated values aren't scraped. The time it was
+// last seen is kept for alerting on it.
+func DeleteSensorValues(id, location string) {
+	labels := prometheus.Labels{
+		SensorID:       id,
+		SensorLocation: location,
+	}
+	temperature.Delete(labels)
+	humidity.Delete(labels)
+	distance.Delete(labels)
+	dewPoint.Delete(labels)
+	absoluteHumidity.Delete(labels)
+	heatIndex.Delete(labels)
+	for _, l := range sensorValueLabels.remove(id, location) {
+		sensorValue.Delete(l)
+	}
+}
+
+// sensorValueLabels remembers the label sets of sensorValue per sensor,
+// which are needed to delete its series.
+var sensorValueLabels = &labelSets{
+	sets: map[string][]prometheus.Labels{},
+}
+
+// labelSets tracks the label sets used with a metric vector, keyed
+// by sensor id and location. It is safe for concurrent use.
+type labelSets struct {
+	mu   sync.Mutex
+	sets map[string][]prometheus.Labels
+}
+
+// add remembers the label set and returns it.
+func (s *labelSets) add(l prometheus.Labels) prometheus.Labels {
+	key := l[SensorID] + "\x00" + l[SensorLocation]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, known := range s.sets[key] {
+		if reflect.DeepEqual(known, l) {
+			return l
+		}
+	}
+	s.sets[key] = append(s.sets[key], l)
+	return l
+}
+
+// remove forgets and returns all label sets of the sensor at the location.
+func (s *labelSets) remove(id, location string) []prometheus.Labels {
+	key := id + "\x00" + location
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sets := s.sets[key]
+	delete(s.sets, key)
+	return sets
+}
@@ -2,8 +2,15 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"sort"
 	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
 )
 
 //go:generate stringer -type DeviceType
@@ -21,19 +28,32 @@ const (
 // Protocol defines a protocol that can be used to match
 // received signals and decode them.
 type Protocol struct {
-	Device    string            // the type of device that uses the protocol
-	SeqLength int               // allowed lengths of the sequence of pulses
-	Lengths   []int             // pulse lengths
-	Mapping   map[string]string // maps the pulse sequence into binary representation (i.e. 0s and 1s)
-	Type      DeviceType
-	Decode    func(string) (interface{}, error) // decodes the binary representation into a human-readable struct
+	Device       string            `yaml:"device"`       // the type of device that uses the protocol
+	SeqLength    int               `yaml:"seqLength"`    // nominal length of the sequence of pulses
+	SeqLengths   []int             `yaml:"seqLengths"`   // further allowed lengths of the sequence of pulses
+	MinSeqLength int               `yaml:"minSeqLength"` // allowed range of sequence lengths,
+	MaxSeqLength int               `yaml:"maxSeqLength"` // each bound is unlimited if unset
+	Tolerance    float64           `yaml:"tolerance"`    // allowed relative deviation of pulse lengths, 0 means DefaultTolerance
+	DedupWindow  time.Duration     `yaml:"dedupWindow"`  // repeated frames within the window are suppressed, 0 means DefaultDedupWindow, negative disables it
+	Lengths      []int             `yaml:"lengths"`      // pulse lengths
+	Mapping      map[string]string `yaml:"mapping"`      // maps the pulse sequence into binary representation (i.e. 0s and 1s)
+	Type         DeviceType        `yaml:"type"`
+	Fields       []Field           `yaml:"fields"`      // bit-field layout of the binary representation
+	Vote         *Vote             `yaml:"vote"`        // only accept frames once several frames of a burst agree
+	Checksums    []Checksum        `yaml:"checksums"`   // integrity bits that are verified before decoding
+	Identity     string            `yaml:"identity"`    // rule the sensors are mapped to a location by, one of "id" (default), "channel" and "protocol-channel"
+	RepairAfter  time.Duration     `yaml:"repairAfter"` // rebind the location of a sensor silent for that long to a new id on the same channel, 0 disables it
+	SensorID     string            `yaml:"sensorId"`    // id of the sensor for devices that don't send one, several such sensors need an id field instead
+	Button       bool              `yaml:"button"`      // the device is a button, e.g. a doorbell, and its signals are button presses
+	Disabled     bool              `yaml:"disabled"`    // removes a built-in protocol when set in a protocol file
+	Decoder      Decoder           `yaml:"-"`           // decodes the binary representation into a human-readable struct
+	Name         string            `yaml:"-"`           // the name the protocol is registered with
 }
 
 // Protocols returns a list of all the currently supported
 // protocols that can be used for trying to decode received signals.
 func Protocols() map[string]*Protocol {
-	return map[string]*Protocol{
-		// Only one protocol supported right now
+	protocols := map[string]*Protocol{
 		// (see: https://github.com/pimatic/rfcontroljs/blob/master/src/protocols/weather15.coffee)
 		"protocol1": {
 			Device:    "Globaltronics GT-WT-01 variant",
@@ -45,40 +65,12 @@ func Protocols() map[string]*Protocol {
 				"03": "",
 			},
 			Type: GT_WT_01,
-			Decode: func(binSeq string) (interface{}, error) {
-				id, err := strconv.ParseUint(binSeq[0:12], 2, 0)
-				if err != nil {
-					return nil, err
-				}
-
-				channel, err := strconv.ParseUint(binSeq[14:16], 2, 0)
-				if err != nil {
-					return nil, err
-				}
-
-				temp, err := strconv.ParseInt(binSeq[16:28], 2, 0)
-				if err != nil {
-					return nil, err
-				}
-
-				humidity, err := strconv.ParseInt(binSeq[28:36], 2, 0)
-				if err != nil {
-					return nil, err
-				}
-
-				lowBattery, err := strconv.ParseBool(string(binSeq[12]))
-				if err != nil {
-					return nil, err
-				}
-
-				return &GTWT01Result{
-					ID:          int(id),
-					Name:        fmt.Sprint(id),
-					Channel:     int(channel) + 1,
-					Temperature: float64(temp) / 10,
-					Humidity:    int(humidity),
-					LowBattery:  lowBattery,
-				}, nil
+			Fields: []Field{
+				{Name: FieldID, Offset: 0, Width: 12},
+				{Name: FieldBattery, Offset: 12, Width: 1},
+				{Name: FieldChannel, Offset: 14, Width: 2, Add: 1},
+				{Name: FieldTemperature, Offset: 16, Width: 12, Signed: true, Scale: 0.1, Unit: UnitCelsius},
+				{Name: FieldHumidity, Offset: 28, Width: 8, Unit: UnitPercent},
 			},
 		},
 		"doorbell": {
@@ -90,10 +82,8 @@ func Protocols() map[string]*Protocol {
 				"1": "1",
 				"2": "",
 			},
-			Type: DoorBell,
-			Decode: func(binSeq string) (interface{}, error) {
-				return nil, nil
-			},
+			Type:   DoorBell,
+			Button: true,
 		},
 		"doorbell-old": {
 			Device:    "Doorbell-old",
@@ -104,10 +94,8 @@ func Protocols() map[string]*Protocol {
 				"1": "1",
 				"2": "",
 			},
-			Type: DoorBellOld,
-			Decode: func(binSeq string) (interface{}, error) {
-				return nil, nil
-			},
+			Type:   DoorBellOld,
+			Button: true,
 		},
 		"doorbell-old-2": {
 			Device:    "Doorbell-old",
@@ -119,10 +107,8 @@ func Protocols() map[string]*Protocol {
 				"2": "",
 				"3": "",
 			},
-			Type: DoorBellOld,
-			Decode: func(binSeq string) (interface{}, error) {
-				return nil, nil
-			},
+			Type:   DoorBellOld,
+			Button: true,
 		},
 		"grube": {
 			Device:    "Grube",
@@ -133,58 +119,317 @@ func Protocols() map[string]*Protocol {
 				"02": "1",
 				"03": "",
 			},
-			Type: Grube,
-			Decode: func(binSeq string) (interface{}, error) {
-				distcm, err := strconv.ParseUint(binSeq[0:16], 2, 16)
-				if err != nil {
-					return nil, err
-				}
-				tmp, err := strconv.ParseUint(binSeq[16:32], 2, 16)
-				if err != nil {
-					return nil, err
-				}
-				temFloat := float64(int16(tmp)) / float64(10)
-				humid, err := strconv.ParseUint(binSeq[32:], 2, 16)
-				if err != nil {
-					return nil, err
-				}
-				humidFloat := float64(humid) / float64(10)
-				log.Println(distcm, temFloat, humidFloat)
-				return &GrubeData{
-					dist:     int(distcm),
-					temp:     temFloat,
-					humidity: humidFloat,
-					Name:     "Grube",
-					ID:       "200",
-				}, nil
+			Type:     Grube,
+			SensorID: "200",
+			Fields: []Field{
+				{Name: FieldDistance, Offset: 0, Width: 16, Unit: UnitCentimeter},
+				{Name: FieldTemperature, Offset: 16, Width: 16, Signed: true, Scale: 0.1, Unit: UnitCelsius},
+				{Name: FieldHumidity, Offset: 32, Scale: 0.1, Unit: UnitPercent},
 			},
 		},
 	}
+	for name, p := range protocols {
+		p.Name = name
+	}
+	return protocols
+}
+
+// LoadProtocols reads protocol definitions from a YAML (or JSON) file
+// and merges them into the built-in protocols. Entries with the name of
+// a built-in protocol replace it, entries marked as disabled remove it.
+func LoadProtocols(path string) (map[string]*Protocol, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read protocol file")
+	}
+
+	var file struct {
+		Protocols map[string]*Protocol `yaml:"protocols"`
+	}
+	if err := yaml.UnmarshalStrict(b, &file); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse protocol file '%s'", path)
+	}
+
+	return MergeProtocols(Protocols(), file.Protocols)
+}
+
+// MergeProtocols adds the protocol definitions to the given protocols,
+// replacing those with the same name. Definitions that are empty or
+// disabled remove the protocol.
+func MergeProtocols(protocols, definitions map[string]*Protocol) (map[string]*Protocol, error) {
+	for name, p := range definitions {
+		if p == nil || p.Disabled {
+			delete(protocols, name)
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "Invalid protocol '%s'", name)
+		}
+		p.Name = name
+		protocols[name] = p
+	}
+	if err := checkDistinguishable(protocols); err != nil {
+		return nil, err
+	}
+	return protocols, nil
+}
+
+// checkDistinguishable rejects protocols that match the same signals
+// equally well unless they decode an id. Otherwise the same one always
+// wins, e.g. of two copies of a protocol that differ only in their
+// sensorId, and the sensors of the others are never received.
+func checkDistinguishable(protocols map[string]*Protocol) error {
+	names := make([]string, 0, len(protocols))
+	for name := range protocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			p, q := protocols[a], protocols[b]
+			if p.hasField(FieldID) && q.hasField(FieldID) {
+				continue
+			}
+			if reflect.DeepEqual(p.signal(), q.signal()) {
+				return fmt.Errorf("Protocols '%s' and '%s' match the same signals, "+
+					"sensors sending them must be told apart by an id field", a, b)
+			}
+		}
+	}
+	return nil
+}
+
+// signal returns what signals are matched against the protocol.
+func (p *Protocol) signal() interface{} {
+	return struct {
+		SeqLength                  int
+		SeqLengths                 []int
+		MinSeqLength, MaxSeqLength int
+		Tolerance                  float64
+		Lengths                    []int
+		Mapping                    map[string]string
+	}{p.SeqLength, p.SeqLengths, p.MinSeqLength, p.MaxSeqLength, p.tolerance(), p.Lengths, p.Mapping}
+}
+
+// hasField reports whether the protocol decodes the named field.
+func (p *Protocol) hasField(name string) bool {
+	for _, f := range p.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks whether the protocol definition is usable for
+// matching and decoding signals.
+func (p *Protocol) Validate() error {
+	if p.SeqLength <= 0 && len(p.SeqLengths) == 0 && !p.seqLengthRange() {
+		return fmt.Errorf("seqLength, seqLengths, minSeqLength or maxSeqLength must be set")
+	}
+	if p.MinSeqLength > p.MaxSeqLength && p.MaxSeqLength > 0 {
+		return fmt.Errorf("minSeqLength must not exceed maxSeqLength")
+	}
+	if p.Tolerance < 0 || p.Tolerance >= 1 {
+		return fmt.Errorf("tolerance must be in [0, 1)")
+	}
+	if len(p.Lengths) == 0 {
+		return fmt.Errorf("at least one pulse length is required")
+	}
+	if len(p.Mapping) == 0 {
+		return fmt.Errorf("mapping must not be empty")
+	}
+	if p.Vote != nil {
+		if err := p.Vote.validate(); err != nil {
+			return err
+		}
+	}
+	if err := validateIdentity(p.Identity); err != nil {
+		return err
+	}
+	for _, c := range p.Checksums {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+	for _, f := range p.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("field at offset %d has no name", f.Offset)
+		}
+		if f.Offset < 0 || f.Width < 0 || f.Width > 64 {
+			return fmt.Errorf("field '%s' has an invalid offset or width", f.Name)
+		}
+	}
+	return nil
+}
+
+// DefaultTolerance is the allowed relative deviation of pulse
+// lengths for protocols that don't declare their own.
+const DefaultTolerance = 0.4
+
+// tolerance returns the allowed relative deviation of pulse lengths.
+func (p *Protocol) tolerance() float64 {
+	if p.Tolerance > 0 {
+		return p.Tolerance
+	}
+	return DefaultTolerance
+}
+
+// identityBits returns the bits of the id and channel fields of the binary
+// representation, which tell the sensors of the protocol apart.
+func (p *Protocol) identityBits(binSeq string) string {
+	var identity string
+	for _, f := range p.Fields {
+		if f.Name != FieldID && f.Name != FieldChannel {
+			continue
+		}
+		if bits, err := f.bits(binSeq); err == nil {
+			identity += bits
+		}
+	}
+	return identity
+}
+
+// seqLengthRange reports whether the protocol allows a range of sequence lengths.
+func (p *Protocol) seqLengthRange() bool {
+	return p.MinSeqLength > 0 || p.MaxSeqLength > 0
+}
+
+// seqLengthFit rates a sequence of n pulses: 1 for the nominal length,
+// less the further n is from it, and false if n isn't allowed at all.
+func (p *Protocol) seqLengthFit(n int) (float64, bool) {
+	inRange := p.seqLengthRange() &&
+		(p.MinSeqLength <= 0 || n >= p.MinSeqLength) &&
+		(p.MaxSeqLength <= 0 || n <= p.MaxSeqLength)
+	allowed := n == p.SeqLength || contains(p.SeqLengths, n) || inRange
+	if n <= 0 || !allowed {
+		return 0, false
+	}
+	if p.SeqLength <= 0 || n == p.SeqLength {
+		return 1, true
+	}
+	fit := 1 - math.Abs(float64(n-p.SeqLength))/float64(p.SeqLength)
+	return math.Max(fit, 0), true
+}
+
+// Names of the fields with a special meaning for readings,
+// and of the measurements of the built-in device types.
+const (
+	FieldID          = "id"
+	FieldChannel     = "channel"
+	FieldBattery     = "battery"
+	FieldTemperature = "temperature"
+	FieldHumidity    = "humidity"
+	FieldDistance    = "distance"
+)
+
+// Field describes how a single value is extracted from
+// the binary representation of a pulse sequence.
+type Field struct {
+	Name   string  `yaml:"name"`
+	Offset int     `yaml:"offset"` // index of the first bit
+	Width  int     `yaml:"width"`  // number of bits, 0 means up to the end
+	Signed bool    `yaml:"signed"` // the bits are a two's complement number
+	Scale  float64 `yaml:"scale"`  // factor applied to the raw value, 0 means 1
+	Add    float64 `yaml:"add"`    // added to the value after scaling
+	Unit   string  `yaml:"unit"`   // unit of the resulting measurement
+}
+
+// bits returns the bits of the field in the binary representation.
+func (f Field) bits(binSeq string) (string, error) {
+	end := len(binSeq)
+	if f.Width > 0 {
+		end = f.Offset + f.Width
+	}
+	if f.Offset >= end || end > len(binSeq) {
+		return "", fmt.Errorf("Field '%s' is out of range of %d bits", f.Name, len(binSeq))
+	}
+	return binSeq[f.Offset:end], nil
 }
 
-// GTWT01Result is the human-readable result of a decoded pulse
-// for the "GT-WT-01 variant".
-type GTWT01Result struct {
-	ID          int
-	Name        string
-	Channel     int
-	Temperature float64
-	Humidity    int
-	LowBattery  bool
+// Extract reads the field from the binary representation.
+func (f Field) Extract(binSeq string) (float64, error) {
+	bits, err := f.bits(binSeq)
+	if err != nil {
+		return 0, err
+	}
+	raw, err := strconv.ParseUint(bits, 2, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to parse field '%s'", f.Name)
+	}
+
+	value := float64(raw)
+	if f.Signed {
+		// sign-extend the two's complement number to 64 bits
+		shift := uint(64 - len(bits))
+		value = float64(int64(raw<<shift) >> shift)
+	}
+
+	if f.Scale != 0 {
+		// round to the precision of the scale to remove floating point noise
+		places := math.Max(0, math.Ceil(-math.Log10(math.Abs(f.Scale))))
+		value = round(value*f.Scale, int(places))
+	}
+	return value + f.Add, nil
 }
 
-func (d GTWT01Result) ReasonableData() bool {
-	return ValidateTempHumid(d.Temperature, d.Humidity)
+// round rounds x to the given number of decimal places, which removes
+// the floating point noise introduced by the scale factor of a field.
+func round(x float64, places int) float64 {
+	pow := math.Pow(10, float64(places))
+	return math.Round(x*pow) / pow
 }
 
-type GrubeData struct {
-	dist           int
-	temp, humidity float64
-	Name           string
-	ID             string
+// Decode verifies the checksums of the binary representation and decodes it using
+// the decoder of the protocol, or the decoder registered for its device type if it has none.
+func (p *Protocol) Decode(binSeq string) (*Reading, error) {
+	for _, c := range p.Checksums {
+		if err := c.Verify(binSeq); err != nil {
+			return nil, err
+		}
+	}
+
+	d := p.Decoder
+	if d == nil {
+		d = newDecoder(p)
+	}
+	r, err := d.Decode(binSeq)
+	if err != nil {
+		return nil, err
+	}
+	r.Protocol = p.Name
+	r.Type = p.Type
+	if r.SensorID == "" {
+		r.SensorID = p.SensorID
+	}
+	return r, nil
+}
+
+// UnmarshalYAML parses a device type from its name, e.g. "GT_WT_01".
+func (i *DeviceType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err != nil {
+		return err
+	}
+	t, err := ParseDeviceType(name)
+	if err != nil {
+		return err
+	}
+	*i = t
+	return nil
+}
+
+// ParseDeviceType returns the device type with the given name.
+func ParseDeviceType(name string) (DeviceType, error) {
+	for i := 0; i < len(_DeviceType_index)-1; i++ {
+		if DeviceType(i).String() == name {
+			return DeviceType(i), nil
+		}
+	}
+	return Unknown, fmt.Errorf("Unknown device type '%s'", name)
 }
 
-func ValidateTempHumid(temp float64, humid int) bool {
+func ValidateTempHumid(temp, humid float64) bool {
 	if temp > 60 || temp < -50 {
 		return false
 	}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -10,12 +13,115 @@ func TestDecode_weather15(t *testing.T) {
 	bits, _ := convert(p.Seq, Protocols()["protocol1"].Mapping)
 	//bits := "1001100101100010000011001000010000111"
 
-	result, _ := Protocols()["protocol1"].Decode(bits)
-	m := result.(*GTWT01Result)
+	m, _ := Protocols()["protocol1"].Decode(bits)
+	humidity, _ := m.Value(FieldHumidity)
+	temperature, _ := m.Value(FieldTemperature)
 
-	assert.Equal(t, 78, m.Humidity, "Humidity")
-	assert.Equal(t, 4.3, m.Temperature, "Temperature")
+	assert.Equal(t, 78.0, humidity, "Humidity")
+	assert.Equal(t, 4.3, temperature, "Temperature")
 	assert.Equal(t, false, m.LowBattery, "LowBattery")
 	assert.Equal(t, 2, m.Channel, "Channel")
-	assert.Equal(t, 2454, m.ID, "Id")
+	assert.Equal(t, "2454", m.SensorID, "Id")
+	assert.Equal(t, GT_WT_01, m.Type)
+}
+
+func TestField_Extract(t *testing.T) {
+	bits := "111111111011" + "0101"
+
+	temp, err := Field{Name: "temp", Offset: 0, Width: 12, Signed: true, Scale: 0.1}.Extract(bits)
+	assert.NoError(t, err)
+	assert.InDelta(t, -0.5, temp, 1e-9)
+
+	channel, err := Field{Name: "channel", Offset: 12, Add: 1}.Extract(bits)
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, channel)
+
+	_, err = Field{Name: "outside", Offset: 10, Width: 8}.Extract(bits)
+	assert.Error(t, err)
+}
+
+func TestLoadProtocols(t *testing.T) {
+	f, err := ioutil.TempFile("", "protocols")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(`
+protocols:
+  doorbell:
+    disabled: true
+  thermo:
+    device: Some thermometer
+    seqLength: 10
+    lengths: [500, 1000, 9000]
+    mapping: {"01": "0", "02": "1", "03": ""}
+    fields:
+      - {name: temperature, offset: 0, width: 4, signed: true, scale: 0.5}
+`)
+	assert.NoError(t, err)
+	f.Close()
+
+	protocols, err := LoadProtocols(f.Name())
+	assert.NoError(t, err)
+	assert.NotContains(t, protocols, "doorbell")
+	assert.Contains(t, protocols, "protocol1")
+
+	result, err := protocols["thermo"].Decode("1110")
+	assert.NoError(t, err)
+	assert.Equal(t, []Measurement{{Name: "temperature", Value: -1}}, result.Measurements)
+}
+
+func TestMergeProtocols_indistinguishable(t *testing.T) {
+	pit := func(id string) *Protocol {
+		p := *Protocols()["grube"]
+		p.SensorID = id
+		return &p
+	}
+	_, err := MergeProtocols(Protocols(), map[string]*Protocol{"grube2": pit("201")})
+	assert.Error(t, err, "the second pit would never be received")
+
+	withID := func(p *Protocol) *Protocol {
+		p.SensorID = ""
+		p.Fields = append([]Field{{Name: FieldID, Offset: 48, Width: 8}}, p.Fields...)
+		return p
+	}
+	_, err = MergeProtocols(Protocols(), map[string]*Protocol{"grube": withID(pit("")), "grube2": withID(pit(""))})
+	assert.NoError(t, err)
+}
+
+func TestDecode_sensorID(t *testing.T) {
+	p := &Protocol{
+		SensorID: "200",
+		Fields:   []Field{{Name: FieldDistance, Offset: 0, Width: 4}},
+	}
+	r, err := p.Decode("0101")
+	assert.NoError(t, err)
+	assert.Equal(t, "200", r.SensorID, "configured id")
+
+	p.Fields = append(p.Fields, Field{Name: FieldID, Offset: 4, Width: 4})
+	r, err = p.Decode("01010011")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", r.SensorID, "decoded id wins")
+}
+
+func TestProtocol_seqLengthFit(t *testing.T) {
+	atLeast := &Protocol{MinSeqLength: 50}
+	atMost := &Protocol{SeqLength: 50, MaxSeqLength: 52}
+	for _, c := range []struct {
+		p    *Protocol
+		n    int
+		want bool
+	}{
+		{atLeast, 49, false},
+		{atLeast, 50, true},
+		{atLeast, 120, true},
+		{atMost, 0, false},
+		{atMost, 1, true},
+		{atMost, 52, true},
+		{atMost, 53, false},
+		{&Protocol{SeqLengths: []int{0}}, 0, false},
+	} {
+		_, ok := c.p.seqLengthFit(c.n)
+		assert.Equal(t, c.want, ok, "%+v with %d pulses", c.p, c.n)
+	}
+	assert.NoError(t, (&Protocol{MinSeqLength: 50, Lengths: []int{500}, Mapping: map[string]string{"01": "0"}}).Validate())
 }
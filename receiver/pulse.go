@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"github.com/bradfitz/slice"
 	"github.com/pkg/errors"
-	"log"
 	"math"
 	"sort"
 	"strconv"
@@ -29,48 +28,43 @@ type Pair struct {
 	second int
 }
 
-// DecodePulse tries to decode a received Signal
-// based on all currently supported protocols.
-func DecodePulse(s *Signal) (DeviceType, interface{}, error) {
-	for _, p := range Protocols() {
-		if matches(s, p) {
-			binary, err := convert(s.Seq, p.Mapping)
-			if err != nil {
-				log.Println(err.Error())
-				return p.Type, nil, err
-			}
-			i, err := p.Decode(binary)
-			return p.Type, i, err
-		}
-	}
-	return Unknown, nil, nil
-}
-
 // matches checks whether a received Signal matches
 // a protocol.
 func matches(s *Signal, p *Protocol) bool {
+	_, ok := score(s, p)
+	return ok
+}
+
+// score rates how well a received Signal matches a protocol, from 0 (barely
+// within the tolerance) to 1 (pulse lengths identical to the protocol and
+// a sequence of the nominal length).
+// The second return value is false if the signal doesn't match at all.
+func score(s *Signal, p *Protocol) (float64, bool) {
 	var i int
-	var maxDelta float64
+	var maxDelta, deviation float64
 
-	// length of the pulse sequence must match
-	if p.SeqLength != len(s.Seq) {
-		return false
+	// length of the pulse sequence must be allowed
+	fit, ok := p.seqLengthFit(len(s.Seq))
+	if !ok {
+		return 0, false
 	}
 
 	// number of pulse length must match
 	if len(s.Lengths) != len(p.Lengths) {
-		return false
+		return 0, false
 	}
 
 	// pulse length must be in a certain range
 	for i < len(s.Lengths) {
-		maxDelta = float64(float64(s.Lengths[i]) * float64(0.4))
-		if math.Abs(float64(s.Lengths[i]-p.Lengths[i])) > maxDelta {
-			return false
+		maxDelta = float64(s.Lengths[i]) * p.tolerance()
+		delta := math.Abs(float64(s.Lengths[i] - p.Lengths[i]))
+		if delta > maxDelta {
+			return 0, false
 		}
+		deviation += delta / maxDelta
 		i++
 	}
-	return true
+	return fit * (1 - deviation/float64(len(s.Lengths))), true
 }
 
 // PreparePulse takes an compressed signal as input,
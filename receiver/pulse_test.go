@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"log"
 	"strconv"
+	"strings"
 )
 
 func TestMatches(t *testing.T) {
@@ -157,3 +158,73 @@ func TestSortSignal_lengthsAlreadySorted(t *testing.T) {
 	sortedSignal, _ := sortSignal(s)
 	assert.Equal(t, s, sortedSignal)
 }
+
+func TestDecodePulse_bestMatchWins(t *testing.T) {
+	r, err := NewRegistry(Protocols())
+	assert.NoError(t, err)
+
+	s := &Signal{
+		Lengths: []int{200, 600, 6044},
+		Seq:     "01010110010110101001101010011001010101101010010112",
+	}
+	match, err := r.DecodePulse(s)
+	assert.NoError(t, err)
+	assert.Equal(t, "doorbell-old", match.Best().Protocol.Name)
+	assert.Equal(t, DoorBellOld, match.Device())
+	assert.Equal(t, 1.0, match.Confidence())
+	assert.False(t, match.Ambiguous())
+}
+
+func TestDecodePulse_ambiguous(t *testing.T) {
+	p := func() *Protocol {
+		return &Protocol{
+			SeqLength: 4,
+			Lengths:   []int{300, 900},
+			Mapping:   map[string]string{"0": "0", "1": "1"},
+		}
+	}
+	r, err := NewRegistry(map[string]*Protocol{"b": p(), "a": p()})
+	assert.NoError(t, err)
+
+	match, err := r.DecodePulse(&Signal{Lengths: []int{310, 880}, Seq: "0110"})
+	assert.NoError(t, err)
+	assert.Len(t, match.Candidates, 2)
+	assert.Equal(t, "a", match.Best().Protocol.Name, "ties are broken by name")
+	assert.True(t, match.Ambiguous())
+}
+
+func TestMatches_variableSeqLength(t *testing.T) {
+	p := &Protocol{
+		SeqLength:    76,
+		SeqLengths:   []int{74},
+		MinSeqLength: 78,
+		MaxSeqLength: 80,
+		Lengths:      []int{496, 2048, 4068, 8960},
+	}
+	lengths := []int{516, 2116, 4152, 9112}
+
+	for n, want := range map[int]bool{72: false, 74: true, 76: true, 77: false, 78: true, 80: true, 82: false} {
+		s := &Signal{Lengths: lengths, Seq: strings.Repeat("0", n)}
+		assert.Equal(t, want, matches(s, p), "sequence length %d", n)
+	}
+
+	nominal, _ := score(&Signal{Lengths: lengths, Seq: strings.Repeat("0", 76)}, p)
+	truncated, _ := score(&Signal{Lengths: lengths, Seq: strings.Repeat("0", 74)}, p)
+	assert.True(t, nominal > truncated, "the nominal length scores best")
+}
+
+func TestMatches_tolerance(t *testing.T) {
+	s := &Signal{
+		Lengths: []int{516, 2116, 4152, 9112},
+		Seq:     "0102020101020201020101020102010202020202020202010201010202010202020202020103",
+	}
+	p := &Protocol{
+		SeqLength: 76,
+		Lengths:   []int{496, 2048, 4068, 8960},
+		Tolerance: 0.03,
+	}
+	assert.False(t, matches(s, p))
+
+	p.Tolerance = 0.05
+	assert.True(t, matches(s, p))
+}
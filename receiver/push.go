@@ -125,6 +125,26 @@ func (s *Server) SendPushes(delete string) {
 		s.sending()
 	}
 
+	s.send(map[string]string{
+		"ring":   "yes",
+		"delete": delete,
+	})
+}
+
+// SendAlert sends an alert, e.g. about a sensor that stopped reporting,
+// to all registered devices.
+func (s *Server) SendAlert(a *Alert) {
+	s.send(map[string]string{
+		"alert":    a.Name,
+		"location": a.Location,
+		"resolved": fmt.Sprint(a.Resolved),
+		"message":  a.Message,
+	})
+}
+
+// send sends a data message to all registered devices
+// and removes the tokens of devices that can't be reached.
+func (s *Server) send(data map[string]string) {
 	keys, err := s.db.Keys(tokenPattern).Result()
 	if err != nil {
 		log.Println("Error getting keys", err)
@@ -139,10 +159,7 @@ func (s *Server) SendPushes(delete string) {
 		}
 		ttl := time.Minute * 10
 		message := &messaging.Message{
-			Data: map[string]string{
-				"ring":   "yes",
-				"delete": delete,
-			},
+			Data: data,
 			Android: &messaging.AndroidConfig{
 				Priority: "high",
 				TTL:      &ttl,
@@ -166,7 +183,7 @@ func (s *Server) SendPushes(delete string) {
 			continue
 		}
 		// Response is a message ID string.
-		log.Println("Delete:", delete, "Successfully sent message:", response)
+		log.Println("Data:", data, "Successfully sent message:", response)
 	}
 }
 
@@ -190,3 +207,23 @@ func (s *Server) startCancel() {
 		}
 	}
 }
+
+// SinkPush is the name of the sink sending push notifications.
+const SinkPush = "push"
+
+// PushSink sends push notifications when a button was pressed
+// and on alerts.
+type PushSink struct {
+	*Server
+}
+
+// Handle rings all registered devices on button events
+// and forwards alerts to them.
+func (s PushSink) Handle(e Event) {
+	switch e.Kind {
+	case ButtonEvent:
+		s.SendPushes("no")
+	case AlertEvent:
+		s.SendAlert(e.Alert)
+	}
+}
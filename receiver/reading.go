@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Units of the measurements of a reading.
+const (
+	UnitCelsius    = "celsius"
+	UnitPercent    = "percent"
+	UnitCentimeter = "centimeter"
+)
+
+// Measurement is a single named value of a reading, e.g. the temperature.
+type Measurement struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// Reading is the device-independent result of a decoded signal.
+// Every decoder produces a reading, so consumers of readings
+// don't need to know about the device that sent it.
+type Reading struct {
+	Protocol     string        `json:"protocol"` // name of the protocol that decoded the signal
+	Type         DeviceType    `json:"type"`
+	SensorID     string        `json:"id"`
+	Key          string        `json:"key,omitempty"`      // identity of the sensor that is mapped to a location, see SensorKey
+	Location     string        `json:"location,omitempty"` // set if the sensor itself knows its location
+	Channel      int           `json:"channel,omitempty"`
+	Receiver     string        `json:"receiver,omitempty"` // that received the signal
+	Time         time.Time     `json:"time"`
+	HasBattery   bool          `json:"-"` // whether the sensor reports LowBattery at all
+	LowBattery   bool          `json:"lowBattery"`
+	Measurements []Measurement `json:"measurements,omitempty"`
+}
+
+// Value returns the value of the named measurement.
+func (r *Reading) Value(name string) (float64, bool) {
+	for _, m := range r.Measurements {
+		if m.Name == name {
+			return m.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Set sets the value of the named measurement, adding it if necessary.
+func (r *Reading) Set(name string, value float64, unit string) {
+	for i := range r.Measurements {
+		if r.Measurements[i].Name == name {
+			r.Measurements[i].Value = value
+			r.Measurements[i].Unit = unit
+			return
+		}
+	}
+	r.Measurements = append(r.Measurements, Measurement{name, value, unit})
+}
+
+// Reasonable checks the temperature and humidity
+// of the reading, if it has any, for plausibility.
+func (r *Reading) Reasonable() bool {
+	// missing values are 0, which is plausible
+	temp, _ := r.Value(FieldTemperature)
+	humid, _ := r.Value(FieldHumidity)
+	return ValidateTempHumid(temp, humid)
+}
+
+func (r *Reading) String() string {
+	s := fmt.Sprintf("%v %s", r.Type, r.SensorID)
+	if r.Channel != 0 {
+		s += fmt.Sprintf(" ch%d", r.Channel)
+	}
+	if r.LowBattery {
+		s += " (low battery)"
+	}
+	for _, m := range r.Measurements {
+		s += fmt.Sprintf(" %s=%v", m.Name, m.Value)
+	}
+	return s
+}
+
+// MarshalText makes device types readable in JSON.
+func (i DeviceType) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
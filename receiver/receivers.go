@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Line is a raw line read from a receiver.
+type Line struct {
+	Receiver string
+	Text     string
+	Time     time.Time
+}
+
+// Receivers are the devices signals are received with, e.g. at
+// both ends of the house.
+type Receivers []*Device
+
+// Lines merges the lines read from all receivers into one channel,
+// which is closed once reading from all of them stopped.
+func (rs Receivers) Lines() <-chan Line {
+	lines := make(chan Line)
+	var wg sync.WaitGroup
+	for _, d := range rs {
+		wg.Add(1)
+		go func(d *Device) {
+			defer wg.Done()
+			for text := range d.readChan {
+				lines <- Line{Receiver: d.name, Text: text, Time: time.Now()}
+			}
+			log.Printf("Stopped reading from '%s'\n", d.name)
+		}(d)
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+	return lines
+}
+
+// Close stops reading from all receivers.
+func (rs Receivers) Close() {
+	for _, d := range rs {
+		if err := d.Close(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// ServeHTTP responds with the status of all receivers as JSON. The status
+// code is 503 Service Unavailable while any of them is disconnected.
+func (rs Receivers) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	list := make([]DeviceStatus, 0, len(rs))
+	healthy := true
+	for _, d := range rs {
+		s := d.Status()
+		healthy = healthy && s.State != StateDisconnected
+		list = append(list, s)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceivers_Lines(t *testing.T) {
+	var receivers Receivers
+	writers := map[string]*io.PipeWriter{}
+	for _, name := range []string{"attic", "cellar"} {
+		opened := make(chan *io.PipeWriter, 1)
+		receivers = append(receivers, OpenSource(namedSource{pipeSource(opened), name}, 0))
+		writers[name] = <-opened
+	}
+
+	lines := receivers.Lines()
+	io.WriteString(writers["cellar"], "RF receive 1 2\n")
+	l := <-lines
+	assert.Equal(t, "cellar", l.Receiver)
+	assert.Equal(t, "RF receive 1 2", l.Text)
+	io.WriteString(writers["attic"], "RF receive 1 2\n")
+	assert.Equal(t, "attic", (<-lines).Receiver)
+
+	rec := httptest.NewRecorder()
+	receivers.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"device":"attic"`)
+
+	receivers.Close()
+	_, ok := <-lines
+	assert.False(t, ok)
+}
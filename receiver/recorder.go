@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Outcomes of decoding a raw line.
+const (
+	OutcomeReading   = "reading"
+	OutcomeButton    = "button"
+	OutcomeInvalid   = "invalid"   // not a valid compressed signal
+	OutcomeUnknown   = "unknown"   // no protocol matched
+	OutcomeChecksum  = "checksum"  // the checksum didn't match
+	OutcomeError     = "error"     // decoding failed
+	OutcomeVoting    = "voting"    // the frame is part of a burst that is voted on
+	OutcomeDuplicate = "duplicate" // the frame was a repeat or heard by another receiver
+	OutcomeRejected  = "rejected"  // the reading was rejected by the spike filter
+)
+
+// Defaults for rotating the recording.
+const (
+	DefaultRecordingMaxSize  = 10 << 20
+	DefaultRecordingMaxFiles = 5
+)
+
+// rotateRetryInterval is how long the recording isn't rotated after it failed.
+const rotateRetryInterval = time.Minute
+
+// Record is a raw line and the outcome of decoding it.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Receiver string    `json:"receiver"`
+	Line     string    `json:"line"`
+	Outcome  string    `json:"outcome"`
+	Protocol string    `json:"protocol,omitempty"`
+	Binary   string    `json:"binary,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Reading  *Reading  `json:"reading,omitempty"`
+}
+
+// RecordingConfig is the configuration of the recording of raw lines.
+type RecordingConfig struct {
+	Path     string `yaml:"path"`
+	MaxSize  int64  `yaml:"maxSize"`  // in bytes, after which the file is rotated
+	MaxFiles int    `yaml:"maxFiles"` // number of rotated files that are kept
+	Enabled  bool   `yaml:"enabled"`
+}
+
+// Recorder appends records to a file as JSON lines while it's enabled,
+// e.g. to collect samples of unknown devices. When the file exceeds its
+// maximum size, it is rotated to path.1, path.1 to path.2 and so on. If
+// that fails, records are appended to the file anyway and the error is
+// reported by the API. It is safe for concurrent use.
+type Recorder struct {
+	mu          sync.Mutex
+	path        string
+	maxSize     int64
+	maxFiles    int
+	file        *os.File
+	size        int64
+	err         error     // of the last failed write or rotation
+	rotateAfter time.Time // the last rotation failed
+}
+
+// NewRecorder creates a disabled recorder writing to the file at path.
+func NewRecorder(path string, maxSize int64, maxFiles int) *Recorder {
+	if maxSize <= 0 {
+		maxSize = DefaultRecordingMaxSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultRecordingMaxFiles
+	}
+	return &Recorder{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+}
+
+// Enabled reports whether records are written.
+func (r *Recorder) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file != nil
+}
+
+// Enable starts or stops writing records.
+func (r *Recorder) Enable(enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case enabled && r.file == nil:
+		if r.path == "" {
+			return fmt.Errorf("No recording file configured")
+		}
+		log.Printf("Recording raw signals to '%s'\n", r.path)
+		r.err = nil
+		r.rotateAfter = time.Time{}
+		return r.open()
+	case !enabled && r.file != nil:
+		log.Println("Stopped recording raw signals")
+		err := r.file.Close()
+		r.file = nil
+		return errors.Wrap(err, "Failed to close recording")
+	}
+	return nil
+}
+
+// Record writes the record if the recorder is enabled.
+func (r *Recorder) Record(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	b = append(b, '\n')
+	if r.size > 0 && r.size+int64(len(b)) > r.maxSize && time.Now().After(r.rotateAfter) {
+		if err := r.rotate(); err != nil {
+			log.Println(err)
+			r.err = err
+			r.rotateAfter = time.Now().Add(rotateRetryInterval)
+		}
+	}
+	n, err := r.file.Write(b)
+	r.size += int64(n)
+	if err != nil {
+		r.err = errors.Wrap(err, "Failed to write recording")
+		log.Println(r.err)
+	}
+}
+
+// Err returns the error of the last write or rotation that failed, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// open opens the file for appending. The caller must hold the lock.
+func (r *Recorder) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrap(err, "Failed to open recording")
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "Failed to open recording")
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate moves the file to path.1, shifting older files and removing
+// the oldest one, and opens a new file. If that fails, the current file
+// is kept open. The caller must hold the lock.
+func (r *Recorder) rotate() error {
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "Failed to rotate recording")
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return errors.Wrap(err, "Failed to rotate recording")
+	}
+	// the open file was moved along, so it is still written to if opening fails
+	rotated := r.file
+	if err := r.open(); err != nil {
+		return err
+	}
+	if err := rotated.Close(); err != nil {
+		log.Println(err)
+	}
+	r.err = nil
+	return nil
+}
+
+// ServeHTTP implements the API for toggling the recording:
+//
+//	GET /recording  returns whether raw signals are recorded
+//	PUT /recording  starts or stops recording, e.g. {"enabled": true}
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var status struct {
+		Enabled bool   `json:"enabled"`
+		Path    string `json:"path,omitempty"`
+		Error   string `json:"error,omitempty"` // of the last failed write or rotation
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if err := json.NewDecoder(req.Body).Decode(&status); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := r.Enable(status.Enabled); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status.Enabled = r.Enabled()
+	status.Path = r.path
+	if err := r.Err(); err != nil {
+		status.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder_rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recording.jsonl")
+
+	r := NewRecorder(path, 300, 2)
+	rec := Record{Time: time.Now(), Receiver: "attic", Line: "RF receive 1 2 3", Outcome: OutcomeUnknown}
+	r.Record(rec)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "disabled")
+
+	assert.NoError(t, r.Enable(true))
+	for i := 0; i < 10; i++ {
+		r.Record(rec)
+	}
+	assert.NoError(t, r.Enable(false))
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	assert.True(t, scanner.Scan())
+	var read Record
+	assert.NoError(t, json.Unmarshal(scanner.Bytes(), &read))
+	assert.Equal(t, "attic", read.Receiver)
+	assert.Equal(t, OutcomeUnknown, read.Outcome)
+
+	for _, name := range []string{"recording.jsonl.1", "recording.jsonl.2"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.True(t, info.Size() <= 300)
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err), "only maxFiles are kept")
+}
+
+func TestRecorder_rotateFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recording.jsonl")
+	// a non-empty directory can't be replaced by the recording, even as root
+	assert.NoError(t, os.MkdirAll(filepath.Join(path+".1", "blocked"), 0755))
+
+	r := NewRecorder(path, 300, 1)
+	assert.NoError(t, r.Enable(true))
+	rec := Record{Time: time.Now(), Receiver: "attic", Line: "RF receive 1 2 3", Outcome: OutcomeUnknown}
+	for i := 0; i < 10; i++ {
+		r.Record(rec)
+	}
+	assert.True(t, r.Enabled())
+	assert.Error(t, r.Err())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recording", nil))
+	assert.Contains(t, w.Body.String(), `"enabled":true`)
+	assert.Contains(t, w.Body.String(), "Failed to rotate recording")
+	assert.NoError(t, r.Enable(false))
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, strings.Count(string(b), "\n"), "records are kept")
+}
+
+func TestRecorder_ServeHTTP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	r := NewRecorder(filepath.Join(dir, "recording.jsonl"), 0, 0)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/recording", strings.NewReader(`{"enabled": true}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, r.Enabled())
+	assert.Contains(t, rec.Body.String(), `"enabled":true`)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/recording", strings.NewReader(`{"enabled": false}`)))
+	assert.False(t, r.Enabled())
+
+	assert.Error(t, NewRecorder("", 0, 0).Enable(true))
+}
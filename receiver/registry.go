@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Decoder decodes the binary representation of a pulse sequence into a reading.
+type Decoder interface {
+	Decode(binSeq string) (*Reading, error)
+}
+
+// DecoderFunc adapts an ordinary function to the Decoder interface.
+type DecoderFunc func(binSeq string) (*Reading, error)
+
+// Decode calls f(binSeq).
+func (f DecoderFunc) Decode(binSeq string) (*Reading, error) {
+	return f(binSeq)
+}
+
+// FieldDecoder decodes the fields of a protocol into a reading.
+// The fields "id", "channel" and "battery" become the sensor id, channel
+// and low battery flag of the reading, all other fields its measurements.
+// It is used for all protocols of device types without their own decoder.
+type FieldDecoder []Field
+
+// Decode extracts all fields from the binary representation.
+// Protocols without fields, e.g. doorbells, decode to a reading
+// without measurements.
+func (d FieldDecoder) Decode(binSeq string) (*Reading, error) {
+	r := &Reading{}
+	for _, f := range d {
+		v, err := f.Extract(binSeq)
+		if err != nil {
+			return nil, err
+		}
+		switch f.Name {
+		case FieldID:
+			r.SensorID = fmt.Sprint(int64(v))
+		case FieldChannel:
+			r.Channel = int(v)
+		case FieldBattery:
+			r.HasBattery = true
+			r.LowBattery = v != 0
+		default:
+			r.Set(f.Name, v, f.Unit)
+		}
+	}
+	return r, nil
+}
+
+var (
+	deviceDecodersMu sync.RWMutex
+	deviceDecoders   = map[DeviceType]func(*Protocol) Decoder{}
+)
+
+// RegisterDeviceDecoder makes a decoder for a device type available to all
+// protocols of that type that don't bring their own decoder.
+// It is meant to be called from the init function of the file implementing
+// the device family.
+func RegisterDeviceDecoder(t DeviceType, newDecoder func(*Protocol) Decoder) {
+	deviceDecodersMu.Lock()
+	defer deviceDecodersMu.Unlock()
+	if _, dup := deviceDecoders[t]; dup {
+		panic(fmt.Sprintf("Decoder for device type %v registered twice", t))
+	}
+	deviceDecoders[t] = newDecoder
+}
+
+// newDecoder returns the decoder registered for the device type
+// of the protocol, or a FieldDecoder if there is none.
+func newDecoder(p *Protocol) Decoder {
+	deviceDecodersMu.RLock()
+	defer deviceDecodersMu.RUnlock()
+	if f, ok := deviceDecoders[p.Type]; ok {
+		return f(p)
+	}
+	return FieldDecoder(p.Fields)
+}
+
+// Registry holds the protocols that are used for decoding received signals.
+// It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	protocols map[string]*Protocol
+}
+
+// NewRegistry creates a registry containing the given protocols.
+func NewRegistry(protocols map[string]*Protocol) (*Registry, error) {
+	r := &Registry{
+		protocols: make(map[string]*Protocol, len(protocols)),
+	}
+	for name, p := range protocols {
+		if err := r.Register(name, p); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a protocol under the given name. If the protocol
+// has no decoder, the one registered for its device type is used.
+func (r *Registry) Register(name string, p *Protocol) error {
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("Invalid protocol '%s': %v", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.protocols[name]; dup {
+		return fmt.Errorf("Protocol '%s' is already registered", name)
+	}
+	p.Name = name
+	if p.Decoder == nil {
+		p.Decoder = newDecoder(p)
+	}
+	r.protocols[name] = p
+	return nil
+}
+
+// Replace replaces all registered protocols, e.g. after the configuration
+// was reloaded. The registry is left unchanged if a protocol is invalid.
+func (r *Registry) Replace(protocols map[string]*Protocol) error {
+	n, err := NewRegistry(protocols)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.protocols = n.protocols
+	return nil
+}
+
+// Lookup returns the protocol registered under the given name.
+func (r *Registry) Lookup(name string) (*Protocol, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.protocols[name]
+	return p, ok
+}
+
+// List returns all registered protocols sorted by name.
+func (r *Registry) List() []*Protocol {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Protocol, 0, len(r.protocols))
+	for _, p := range r.protocols {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r, err := NewRegistry(Protocols())
+	assert.NoError(t, err)
+
+	custom := &Protocol{
+		SeqLength: 4,
+		Lengths:   []int{300, 900},
+		Mapping:   map[string]string{"0": "0", "1": "1"},
+		Decoder: DecoderFunc(func(binSeq string) (*Reading, error) {
+			return &Reading{SensorID: binSeq}, nil
+		}),
+	}
+	assert.NoError(t, r.Register("custom", custom))
+	assert.Error(t, r.Register("custom", custom), "duplicate name")
+	assert.Error(t, r.Register("invalid", &Protocol{}), "invalid protocol")
+
+	p, ok := r.Lookup("custom")
+	assert.True(t, ok)
+	result, err := p.Decode("0101")
+	assert.NoError(t, err)
+	assert.Equal(t, "0101", result.SensorID)
+	assert.Equal(t, "custom", result.Protocol)
+
+	var names []string
+	for _, p := range r.List() {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"custom", "doorbell", "doorbell-old", "doorbell-old-2", "grube", "protocol1"}, names)
+
+	grube, _ := r.Lookup("grube")
+	result, err = grube.Decode("000000000000101000000000111101000000000110100011")
+	assert.NoError(t, err)
+	assert.Equal(t, &Reading{
+		Protocol: "grube",
+		Type:     Grube,
+		SensorID: "200",
+		Measurements: []Measurement{
+			{FieldDistance, 10, UnitCentimeter},
+			{FieldTemperature, 24.4, UnitCelsius},
+			{FieldHumidity, 41.9, UnitPercent},
+		},
+	}, result)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.bug.st/serial.v1"
+	"go.bug.st/serial.v1/enumerator"
+)
+
+// DefaultBaudRate is the baud rate the Arduino sketch uses.
+const DefaultBaudRate = 115200
+
+// resetPulse is how long DTR is held low to reset the Arduino.
+const resetPulse = 100 * time.Millisecond
+
+// SerialConfig is the configuration of the serial port the Arduino is
+// connected to. The port is either given by the path of its device file
+// or selected by the USB ids of the device, which don't change between
+// reboots. The USB ids take precedence over the path.
+type SerialConfig struct {
+	Device       string        `yaml:"device"`
+	VID          string        `yaml:"vid"` // USB vendor id, e.g. 1a86
+	PID          string        `yaml:"pid"` // USB product id, e.g. 7523
+	SerialNumber string        `yaml:"serialNumber"`
+	BaudRate     int           `yaml:"baudRate"`
+	DataBits     int           `yaml:"dataBits"`    // 0 means 8
+	Parity       string        `yaml:"parity"`      // none, odd, even, mark or space
+	StopBits     string        `yaml:"stopBits"`    // 1, 1.5 or 2
+	DTR          *bool         `yaml:"dtr"`         // level of DTR after opening the port, unchanged if not set
+	RTS          *bool         `yaml:"rts"`         // level of RTS after opening the port, unchanged if not set
+	Reset        bool          `yaml:"reset"`       // reset the Arduino by pulsing DTR after opening the port
+	ReadTimeout  time.Duration `yaml:"readTimeout"` // reopen the port if nothing was read for this long, 0 disables it
+}
+
+var parities = map[string]serial.Parity{
+	"":      serial.NoParity,
+	"none":  serial.NoParity,
+	"odd":   serial.OddParity,
+	"even":  serial.EvenParity,
+	"mark":  serial.MarkParity,
+	"space": serial.SpaceParity,
+}
+
+var stopBits = map[string]serial.StopBits{
+	"":    serial.OneStopBit,
+	"1":   serial.OneStopBit,
+	"1.5": serial.OnePointFiveStopBits,
+	"2":   serial.TwoStopBits,
+}
+
+// validate checks whether the port can be opened with the configuration.
+func (c *SerialConfig) validate() error {
+	if c.Device == "" && c.VID == "" && c.PID == "" && c.SerialNumber == "" {
+		return fmt.Errorf("device or USB ids of the serial port must be set")
+	}
+	if c.BaudRate <= 0 {
+		return fmt.Errorf("baudRate must be positive")
+	}
+	if c.DataBits != 0 && (c.DataBits < 5 || c.DataBits > 8) {
+		return fmt.Errorf("dataBits must be in [5, 8]")
+	}
+	if _, ok := parities[c.Parity]; !ok {
+		return fmt.Errorf("unknown parity '%s'", c.Parity)
+	}
+	if _, ok := stopBits[c.StopBits]; !ok {
+		return fmt.Errorf("unknown number of stop bits '%s'", c.StopBits)
+	}
+	if c.ReadTimeout < 0 {
+		return fmt.Errorf("readTimeout must not be negative")
+	}
+	return nil
+}
+
+// usb reports whether the port is selected by the USB ids of the device.
+func (c *SerialConfig) usb() bool {
+	return c.VID != "" || c.PID != "" || c.SerialNumber != ""
+}
+
+// String returns the path of the device file or the USB ids of the device.
+func (c SerialConfig) String() string {
+	if !c.usb() {
+		return c.Device
+	}
+	s := "usb:" + c.VID + ":" + c.PID
+	if c.SerialNumber != "" {
+		s += ":" + c.SerialNumber
+	}
+	return s
+}
+
+func (c *SerialConfig) mode() *serial.Mode {
+	dataBits := c.DataBits
+	if dataBits == 0 {
+		dataBits = 8
+	}
+	return &serial.Mode{
+		BaudRate: c.BaudRate,
+		DataBits: dataBits,
+		Parity:   parities[c.Parity],
+		StopBits: stopBits[c.StopBits],
+	}
+}
+
+// resolve returns the path of the device file, looking up the USB device
+// with the configured ids if the path isn't given.
+func (c *SerialConfig) resolve() (string, error) {
+	if !c.usb() {
+		return c.Device, nil
+	}
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to list serial ports")
+	}
+	return c.match(ports)
+}
+
+// match returns the name of the only USB port with the configured ids.
+func (c *SerialConfig) match(ports []*enumerator.PortDetails) (string, error) {
+	var matches []string
+	for _, p := range ports {
+		if !p.IsUSB ||
+			c.VID != "" && !strings.EqualFold(c.VID, p.VID) ||
+			c.PID != "" && !strings.EqualFold(c.PID, p.PID) ||
+			c.SerialNumber != "" && c.SerialNumber != p.SerialNumber {
+			continue
+		}
+		matches = append(matches, p.Name)
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("No USB serial port matches '%v'", c)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("Several USB serial ports match '%v': %s", c, strings.Join(matches, ", "))
+	}
+}
+
+// openSerial opens the serial port and sets its modem status bits.
+func openSerial(c SerialConfig) (io.ReadCloser, error) {
+	name, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+	port, err := serial.Open(name, c.mode())
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to open '%s'", name)
+	}
+	if err := setModemBits(port, c); err != nil {
+		port.Close()
+		return nil, errors.Wrapf(err, "Failed to configure '%s'", name)
+	}
+	return port, nil
+}
+
+func setModemBits(port serial.Port, c SerialConfig) error {
+	if c.DTR != nil {
+		if err := port.SetDTR(*c.DTR); err != nil {
+			return err
+		}
+	}
+	if c.RTS != nil {
+		if err := port.SetRTS(*c.RTS); err != nil {
+			return err
+		}
+	}
+	if !c.Reset {
+		return nil
+	}
+	if err := port.SetDTR(false); err != nil {
+		return err
+	}
+	time.Sleep(resetPulse)
+	if err := port.SetDTR(true); err != nil {
+		return err
+	}
+	return port.ResetInputBuffer()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.bug.st/serial.v1/enumerator"
+)
+
+func TestSerialConfig_match(t *testing.T) {
+	ports := []*enumerator.PortDetails{
+		{Name: "/dev/ttyS0"},
+		{Name: "/dev/ttyUSB0", IsUSB: true, VID: "1a86", PID: "7523", SerialNumber: "A1"},
+		{Name: "/dev/ttyUSB1", IsUSB: true, VID: "1a86", PID: "7523", SerialNumber: "B2"},
+		{Name: "/dev/ttyACM0", IsUSB: true, VID: "2341", PID: "0043"},
+	}
+
+	c := SerialConfig{VID: "2341", PID: "0043"}
+	name, err := c.match(ports)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/ttyACM0", name)
+
+	c = SerialConfig{VID: "1A86", PID: "7523"}
+	_, err = c.match(ports)
+	assert.Error(t, err, "ambiguous without serial number")
+
+	c.SerialNumber = "B2"
+	name, err = c.match(ports)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/ttyUSB1", name)
+	assert.Equal(t, "usb:1A86:7523:B2", c.String())
+
+	c = SerialConfig{VID: "0403"}
+	_, err = c.match(ports)
+	assert.Error(t, err)
+}
+
+func TestSerialConfig_validate(t *testing.T) {
+	c := SerialConfig{Device: "/dev/ttyUSB0", BaudRate: DefaultBaudRate}
+	assert.NoError(t, c.validate())
+
+	c.Parity = "even"
+	c.StopBits = "2"
+	assert.NoError(t, c.validate())
+
+	c.Parity = "strange"
+	assert.Error(t, c.validate())
+
+	assert.Error(t, (&SerialConfig{BaudRate: DefaultBaudRate}).validate())
+	assert.Error(t, (&SerialConfig{Device: "/dev/ttyUSB0"}).validate())
+}
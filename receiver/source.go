@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Source is where raw lines of a receiver are read from, e.g. the serial
+// port of an Arduino or a TCP connection to a serial bridge. Open is called
+// again whenever reading from the previous connection failed. Sources that
+// can't be reopened, like files, return io.EOF.
+type Source interface {
+	Open() (io.ReadCloser, error)
+	String() string
+}
+
+// dialTimeout is how long connecting to a TCP source may take.
+const dialTimeout = 10 * time.Second
+
+// tcpKeepAlive is the keep-alive period of TCP connections,
+// which detects bridges that disappeared without closing them.
+const tcpKeepAlive = 30 * time.Second
+
+// ParseSource parses the description of a source:
+//
+//	serial                 the serial port of the config
+//	tcp://host:port        connects to a serial bridge like ser2net
+//	tcp-listen://:port     waits for a bridge to connect
+//	stdin or -             reads the standard input
+//	file://path            reads a recorded file once
+func ParseSource(spec string, serial SerialConfig) (Source, error) {
+	if spec == "" || spec == "serial" {
+		if err := serial.validate(); err != nil {
+			return nil, errors.Wrap(err, "Invalid configuration of the serial port")
+		}
+		return serialSource(serial), nil
+	}
+	if spec == "stdin" || spec == "-" {
+		return &onceSource{name: "stdin", open: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(os.Stdin), nil
+		}}, nil
+	}
+
+	parts := strings.SplitN(spec, "://", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("Invalid input '%s'", spec)
+	}
+	switch scheme, addr := parts[0], parts[1]; scheme {
+	case "tcp":
+		return tcpClient(addr), nil
+	case "tcp-listen":
+		return &tcpServer{addr: addr}, nil
+	case "file":
+		return &onceSource{name: spec, open: func() (io.ReadCloser, error) {
+			return os.Open(addr)
+		}}, nil
+	default:
+		return nil, fmt.Errorf("Unknown input '%s'", scheme)
+	}
+}
+
+// serialSource opens the serial port an Arduino is connected to.
+type serialSource SerialConfig
+
+func (s serialSource) Open() (io.ReadCloser, error) {
+	return openSerial(SerialConfig(s))
+}
+
+func (s serialSource) String() string {
+	return SerialConfig(s).String()
+}
+
+// tcpClient connects to the address of a serial bridge.
+type tcpClient string
+
+func (s tcpClient) Open() (io.ReadCloser, error) {
+	d := net.Dialer{Timeout: dialTimeout, KeepAlive: tcpKeepAlive}
+	conn, err := d.Dial("tcp", string(s))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to connect to '%s'", string(s))
+	}
+	return conn, nil
+}
+
+func (s tcpClient) String() string {
+	return "tcp://" + string(s)
+}
+
+// tcpServer listens on an address and reads from one connection at a
+// time, e.g. of an ESP8266 bridge. A new connection replaces the one being
+// read from, since a bridge that connects again has lost the old one.
+type tcpServer struct {
+	addr string
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    chan net.Conn
+	current  net.Conn
+	done     chan struct{}
+}
+
+func (s *tcpServer) Open() (io.ReadCloser, error) {
+	s.mu.Lock()
+	if s.listener == nil {
+		l, err := net.Listen("tcp", s.addr)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, errors.Wrapf(err, "Failed to listen on '%s'", s.addr)
+		}
+		s.listener = l
+		s.conns = make(chan net.Conn)
+		s.done = make(chan struct{})
+		go s.accept(l)
+	}
+	conns, done := s.conns, s.done
+	s.mu.Unlock()
+
+	select {
+	case conn := <-conns:
+		return conn, nil
+	case <-done:
+		return nil, fmt.Errorf("Stopped listening on '%s'", s.addr)
+	}
+}
+
+// accept accepts connections until the listener is closed
+// and closes the previous connection for every new one.
+func (s *tcpServer) accept(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			log.Printf("Failed to accept a connection on '%s': %v\n", s.addr, err)
+			time.Sleep(time.Second)
+			continue
+		}
+		log.Printf("Receiver connected from %v\n", conn.RemoteAddr())
+		if c, ok := conn.(*net.TCPConn); ok {
+			c.SetKeepAlive(true)
+			c.SetKeepAlivePeriod(tcpKeepAlive)
+		}
+
+		s.mu.Lock()
+		if s.current != nil {
+			s.current.Close()
+		}
+		s.current = conn
+		s.mu.Unlock()
+
+		select {
+		case s.conns <- conn:
+		case <-s.done:
+			conn.Close()
+			return
+		}
+	}
+}
+
+// Close stops listening.
+func (s *tcpServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+	close(s.done)
+	return s.listener.Close()
+}
+
+func (s *tcpServer) String() string {
+	return "tcp-listen://" + s.addr
+}
+
+// onceSource is a source that can only be read once, like a file.
+type onceSource struct {
+	name string
+	open func() (io.ReadCloser, error)
+
+	mu     sync.Mutex
+	opened bool
+}
+
+func (s *onceSource) Open() (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opened {
+		return nil, io.EOF
+	}
+	s.opened = true
+	return s.open()
+}
+
+func (s *onceSource) String() string {
+	return s.name
+}
+
+// OpenInput opens the input of the receiver in the background, see
+// ParseSource and OpenSource.
+func OpenInput(c ReceiverConfig) (*Device, error) {
+	s, err := ParseSource(c.Input, c.Serial)
+	if err != nil {
+		return nil, err
+	}
+	timeout := c.ReadTimeout
+	if _, ok := s.(serialSource); ok && timeout == 0 {
+		timeout = c.Serial.ReadTimeout
+	}
+	if c.Name != "" {
+		s = namedSource{s, c.Name}
+	}
+	return OpenSource(s, timeout), nil
+}
+
+// namedSource is a source with a name given by the user.
+type namedSource struct {
+	Source
+	name string
+}
+
+func (s namedSource) String() string {
+	return s.name
+}
+
+// Close closes the source, if it needs to be closed.
+func (s namedSource) Close() error {
+	if c, ok := s.Source.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSource(t *testing.T) {
+	serial := SerialConfig{Device: "/dev/ttyUSB0", BaudRate: DefaultBaudRate}
+	for spec, name := range map[string]string{
+		"":                   "/dev/ttyUSB0",
+		"serial":             "/dev/ttyUSB0",
+		"tcp://bridge:2000":  "tcp://bridge:2000",
+		"tcp-listen://:2000": "tcp-listen://:2000",
+		"-":                  "stdin",
+		"file://signals.txt": "file://signals.txt",
+	} {
+		s, err := ParseSource(spec, serial)
+		assert.NoError(t, err, spec)
+		assert.Equal(t, name, s.String())
+	}
+
+	for _, spec := range []string{"udp://bridge:2000", "tcp://", "signals.txt"} {
+		_, err := ParseSource(spec, serial)
+		assert.Error(t, err, spec)
+	}
+	_, err := ParseSource("serial", SerialConfig{})
+	assert.Error(t, err)
+	_, err = ParseSource("tcp://bridge:2000", SerialConfig{})
+	assert.NoError(t, err, "serial port isn't needed")
+}
+
+func TestOpenInput_file(t *testing.T) {
+	minReconnectDelay = time.Millisecond
+	defer func() { minReconnectDelay = time.Second }()
+
+	dir, err := ioutil.TempDir("", "source")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "signals.txt")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("RF receive 1 2\r\nRF receive 3 4\r\n"), 0644))
+
+	d, err := OpenInput(ReceiverConfig{Input: "file://" + path})
+	assert.NoError(t, err)
+	var lines []string
+	for line := range d.readChan {
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{"RF receive 1 2", "RF receive 3 4"}, lines)
+}
+
+func TestOpenInput_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("RF receive 1 2\n"))
+		conn.Close()
+	}()
+
+	d, err := OpenInput(ReceiverConfig{Name: "bridge", Input: "tcp://" + l.Addr().String()})
+	assert.NoError(t, err)
+	defer d.Close()
+	assert.Equal(t, "RF receive 1 2", <-d.readChan)
+	assert.Equal(t, "bridge", d.Status().Device)
+}
+
+func TestOpenInput_readTimeout(t *testing.T) {
+	minReconnectDelay = time.Millisecond
+	defer func() { minReconnectDelay = time.Second }()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	d, err := OpenInput(ReceiverConfig{Input: "tcp://" + l.Addr().String(), ReadTimeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	defer d.Close()
+	var silent net.Conn
+	select {
+	case silent = <-conns:
+	case <-time.After(time.Second):
+		t.Fatal("not connected")
+	}
+	silent.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = silent.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err, "silent connection is dropped")
+	select {
+	case conn := <-conns:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("no new connection after dropping the silent one")
+	}
+}
+
+func TestOpenInput_tcpListen(t *testing.T) {
+	minReconnectDelay = time.Millisecond
+	defer func() { minReconnectDelay = time.Second }()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	d, err := OpenInput(ReceiverConfig{Input: "tcp-listen://" + addr})
+	assert.NoError(t, err)
+	defer d.Close()
+	dial := func() net.Conn {
+		for i := 0; i < 100; i++ {
+			if conn, err := net.Dial("tcp", addr); err == nil {
+				return conn
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("not listening")
+		return nil
+	}
+
+	old := dial()
+	defer old.Close()
+	old.Write([]byte("RF receive 1 2\n"))
+	assert.Equal(t, "RF receive 1 2", <-d.readChan)
+
+	bridge := dial()
+	defer bridge.Close()
+	bridge.Write([]byte("RF receive 3 4\n"))
+	assert.Equal(t, "RF receive 3 4", <-d.readChan, "a new connection replaces the old one")
+}
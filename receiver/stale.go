@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// AlertStale is the name of alerts about sensors that stopped reporting.
+const AlertStale = "stale"
+
+// MinStaleAfter is the shortest time after which sensors can be considered
+// stale. Most sensors report only about once a minute.
+const MinStaleAfter = time.Minute
+
+// minStaleCheckInterval is the shortest interval stale sensors are checked in.
+const minStaleCheckInterval = time.Second
+
+// lastReading is the time a sensor at a location was last seen.
+type lastReading struct {
+	key, location string
+	time          time.Time
+	stale         bool
+}
+
+// StaleWatcher detects sensors with a location that stopped reporting.
+// The series of their measurements are removed from Prometheus and an
+// alert is published, which is resolved when they report again. It is
+// safe for concurrent use.
+type StaleWatcher struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	bus     *Bus
+	sensors map[string]*lastReading
+}
+
+// NewStaleWatcher creates a watcher that considers sensors stale
+// after they haven't reported for timeout.
+func NewStaleWatcher(timeout time.Duration, bus *Bus) *StaleWatcher {
+	return &StaleWatcher{
+		timeout: timeout,
+		bus:     bus,
+		sensors: map[string]*lastReading{},
+	}
+}
+
+// Handle records the readings of sensors with a location.
+func (w *StaleWatcher) Handle(e Event) {
+	if e.Kind != ReadingEvent || e.Reading.Location == "" {
+		return
+	}
+	r := e.Reading
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := r.Key + "\x00" + r.Location
+	s, ok := w.sensors[id]
+	if !ok {
+		s = &lastReading{key: r.Key, location: r.Location}
+		w.sensors[id] = s
+	}
+	s.time = r.Time
+	if s.stale {
+		s.stale = false
+		w.bus.Publish(Event{Kind: AlertEvent, Alert: &Alert{
+			Name:     AlertStale,
+			Key:      s.key,
+			Location: s.location,
+			Message:  fmt.Sprintf("Sensor %s in %s is reporting again", s.key, s.location),
+			Resolved: true,
+		}})
+	}
+}
+
+// Forget stops watching a sensor, e.g. because it was removed.
+func (w *StaleWatcher) Forget(key, location string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.sensors, key+"\x00"+location)
+}
+
+// Check marks all sensors that haven't reported since now minus the timeout as stale.
+func (w *StaleWatcher) Check(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, s := range w.sensors {
+		if s.stale || now.Sub(s.time) <= w.timeout {
+			continue
+		}
+		s.stale = true
+		log.Printf("Sensor %s in %s hasn't reported since %v\n", s.key, s.location, s.time)
+		DeleteSensorValues(s.key, s.location)
+		w.bus.Publish(Event{Kind: AlertEvent, Alert: &Alert{
+			Name:     AlertStale,
+			Key:      s.key,
+			Location: s.location,
+			Message:  fmt.Sprintf("Sensor %s in %s hasn't reported since %v", s.key, s.location, s.time.Format(time.Kitchen)),
+		}})
+	}
+}
+
+// Run checks for stale sensors periodically.
+func (w *StaleWatcher) Run() {
+	interval := w.timeout / 10
+	if interval < minStaleCheckInterval {
+		interval = minStaleCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	for now := range ticker.C {
+		w.Check(now)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaleWatcher(t *testing.T) {
+	bus := NewBus()
+	alerts := bus.Subscribe("test", 10)
+	w := NewStaleWatcher(time.Hour, bus)
+	start := time.Now()
+
+	w.Handle(Event{Kind: ReadingEvent, Reading: &Reading{Key: "2454", Location: "kitchen", Time: start}})
+	w.Handle(Event{Kind: ReadingEvent, Reading: &Reading{Key: "7", Time: start}})
+
+	w.Check(start.Add(30 * time.Minute))
+	assert.Len(t, alerts, 0)
+
+	w.Check(start.Add(2 * time.Hour))
+	w.Check(start.Add(3 * time.Hour))
+	assert.Len(t, alerts, 1, "alerted once, sensors without location are ignored")
+	a := (<-alerts).Alert
+	assert.Equal(t, AlertStale, a.Name)
+	assert.Equal(t, "kitchen", a.Location)
+	assert.False(t, a.Resolved)
+
+	w.Handle(Event{Kind: ReadingEvent, Reading: &Reading{Key: "2454", Location: "kitchen", Time: start.Add(4 * time.Hour)}})
+	assert.True(t, (<-alerts).Alert.Resolved)
+}
+
+func TestStaleWatcher_keepsLastSeen(t *testing.T) {
+	defer DeleteSensorMetrics("2454", "kitchen")
+	w := NewStaleWatcher(time.Hour, NewBus())
+	start := time.Now()
+	r := &Reading{Key: "2454", Location: "kitchen", Time: start,
+		Measurements: []Measurement{{FieldTemperature, 21, UnitCelsius}}}
+	ExportReading(r)
+	w.Handle(Event{Kind: ReadingEvent, Reading: r})
+
+	w.Check(start.Add(2 * time.Hour))
+	assert.Equal(t, 0, testSeries(temperature), "outdated values are removed")
+	assert.Equal(t, 1, testSeries(lastSeen), "still alertable on the time it was last seen")
+}
+
+// testSeries counts the series of a metric.
+func testSeries(c prometheus.Collector) int {
+	series := make(chan prometheus.Metric, 10)
+	c.Collect(series)
+	close(series)
+	return len(series)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Measurements derived from the distance to the water in a tank.
+const (
+	FieldFill   = "fill"
+	FieldVolume = "volume"
+	UnitLitre   = "litre"
+)
+
+// Shapes of tanks.
+const (
+	TankCylinder = "cylinder" // upright cylinder
+	TankBox      = "box"
+	TankTable    = "table" // calibration table of distances and volumes
+)
+
+// AlertTankLevel is the name of alerts about tanks whose fill level
+// crossed a threshold.
+const AlertTankLevel = "tank-level"
+
+// tankHysteresis is the margin in percent the fill level must move back
+// beyond a threshold before the alert is resolved.
+const tankHysteresis = 2
+
+// TablePoint is a point of a calibration table: the volume
+// in the tank when the sensor measures the distance.
+type TablePoint struct {
+	Distance float64 `json:"distance"` // in cm
+	Volume   float64 `json:"volume"`   // in litres
+}
+
+// Tank describes the geometry of a tank whose water level is measured
+// by the distance from a sensor mounted above it. All lengths are in cm.
+type Tank struct {
+	Shape    string       `json:"shape"`
+	Diameter float64      `json:"diameter,omitempty"` // of cylinders
+	Length   float64      `json:"length,omitempty"`   // of boxes
+	Width    float64      `json:"width,omitempty"`    // of boxes
+	Height   float64      `json:"height,omitempty"`   // from the bottom to the maximum water level
+	Offset   float64      `json:"offset"`             // distance measured at the maximum water level
+	Table    []TablePoint `json:"table,omitempty"`
+	Low      *float64     `json:"low,omitempty"`  // fill level in percent below which an alert is raised
+	High     *float64     `json:"high,omitempty"` // fill level in percent above which an alert is raised
+}
+
+// clone returns a deep copy of the tank.
+func (t Tank) clone() Tank {
+	t.Table = append([]TablePoint(nil), t.Table...)
+	if t.Low != nil {
+		low := *t.Low
+		t.Low = &low
+	}
+	if t.High != nil {
+		high := *t.High
+		t.High = &high
+	}
+	return t
+}
+
+func (t *Tank) validate() error {
+	switch t.Shape {
+	case TankCylinder:
+		if t.Diameter <= 0 || t.Height <= 0 {
+			return fmt.Errorf("cylinder tank needs a diameter and height")
+		}
+	case TankBox:
+		if t.Length <= 0 || t.Width <= 0 || t.Height <= 0 {
+			return fmt.Errorf("box tank needs a length, width and height")
+		}
+	case TankTable:
+		if len(t.Table) < 2 {
+			return fmt.Errorf("tank table needs at least two points")
+		}
+	default:
+		return fmt.Errorf("unknown tank shape '%s'", t.Shape)
+	}
+	return nil
+}
+
+// area returns the base area in cm².
+func (t *Tank) area() float64 {
+	if t.Shape == TankCylinder {
+		return math.Pi * t.Diameter * t.Diameter / 4
+	}
+	return t.Length * t.Width
+}
+
+// Level converts a distance measured by the sensor into the fill level in
+// percent and the volume in litres.
+func (t *Tank) Level(distance float64) (fill, volume float64) {
+	if t.Shape == TankTable {
+		return t.interpolate(distance)
+	}
+	level := math.Min(math.Max(t.Height-(distance-t.Offset), 0), t.Height)
+	return round(100*level/t.Height, 1), round(t.area()*level/1000, 1)
+}
+
+// interpolate looks up the volume for the distance in the calibration table.
+func (t *Tank) interpolate(distance float64) (fill, volume float64) {
+	points := make([]TablePoint, len(t.Table))
+	copy(points, t.Table)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Distance < points[j].Distance
+	})
+
+	var max float64
+	for _, p := range points {
+		max = math.Max(max, p.Volume)
+	}
+
+	switch {
+	case distance <= points[0].Distance:
+		volume = points[0].Volume
+	case distance >= points[len(points)-1].Distance:
+		volume = points[len(points)-1].Volume
+	default:
+		i := sort.Search(len(points), func(i int) bool {
+			return points[i].Distance >= distance
+		})
+		a, b := points[i-1], points[i]
+		volume = a.Volume + (b.Volume-a.Volume)*(distance-a.Distance)/(b.Distance-a.Distance)
+	}
+	if max == 0 {
+		return 0, 0
+	}
+	return round(100*volume/max, 1), round(volume, 1)
+}
+
+// applyTank adds the fill level and volume to a reading with a distance
+// from a sensor in a tank.
+func applyTank(r *Reading, t *Tank) {
+	d, ok := r.Value(FieldDistance)
+	if !ok || t == nil {
+		return
+	}
+	fill, volume := t.Level(d)
+	r.Set(FieldFill, fill, UnitPercent)
+	r.Set(FieldVolume, volume, UnitLitre)
+}
+
+// TankLevel is the last known level of a tank.
+type TankLevel struct {
+	Key      string  `json:"key"`
+	Location string  `json:"location"`
+	Fill     float64 `json:"fill"`
+	Volume   float64 `json:"volume"`
+	low      bool
+	high     bool
+}
+
+// TankWatcher keeps the last level of all tanks and raises alerts when
+// their fill level crosses the thresholds of the tank. It is safe for
+// concurrent use.
+type TankWatcher struct {
+	mu        sync.Mutex
+	bus       *Bus
+	locations *Locations
+	tanks     map[string]*TankLevel
+}
+
+// NewTankWatcher creates a watcher for the tanks configured in locations.
+func NewTankWatcher(bus *Bus, locations *Locations) *TankWatcher {
+	return &TankWatcher{
+		bus:       bus,
+		locations: locations,
+		tanks:     map[string]*TankLevel{},
+	}
+}
+
+// Handle records the level of tanks and checks their thresholds.
+func (w *TankWatcher) Handle(e Event) {
+	if e.Kind != ReadingEvent || e.Reading.Location == "" {
+		return
+	}
+	r := e.Reading
+	fill, ok := r.Value(FieldFill)
+	if !ok {
+		return
+	}
+	volume, _ := r.Value(FieldVolume)
+	c, _ := w.locations.Config(r.Key)
+	if c.Tank == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	t, ok := w.tanks[r.Key]
+	if !ok {
+		t = &TankLevel{Key: r.Key}
+		w.tanks[r.Key] = t
+	}
+	t.Location, t.Fill, t.Volume = r.Location, fill, volume
+
+	if c.Tank.Low != nil {
+		t.low = w.threshold(t, t.low, fill < *c.Tank.Low, fill > *c.Tank.Low+tankHysteresis, "below", *c.Tank.Low)
+	}
+	if c.Tank.High != nil {
+		t.high = w.threshold(t, t.high, fill > *c.Tank.High, fill < *c.Tank.High-tankHysteresis, "above", *c.Tank.High)
+	}
+}
+
+// threshold publishes an alert when a threshold is crossed or crossed back
+// and returns whether the alert is active.
+func (w *TankWatcher) threshold(t *TankLevel, active, crossed, back bool, direction string, limit float64) bool {
+	switch {
+	case !active && crossed:
+		w.bus.Publish(Event{Kind: AlertEvent, Alert: &Alert{
+			Name:     AlertTankLevel,
+			Key:      t.Key,
+			Location: t.Location,
+			Message:  fmt.Sprintf("Tank in %s is %s %.0f%%: %.1f%% (%.0f l)", t.Location, direction, limit, t.Fill, t.Volume),
+		}})
+		return true
+	case active && back:
+		w.bus.Publish(Event{Kind: AlertEvent, Alert: &Alert{
+			Name:     AlertTankLevel,
+			Key:      t.Key,
+			Location: t.Location,
+			Message:  fmt.Sprintf("Tank in %s is back to %.1f%% (%.0f l)", t.Location, t.Fill, t.Volume),
+			Resolved: true,
+		}})
+		return false
+	}
+	return active
+}
+
+// List returns the last level of all tanks.
+func (w *TankWatcher) List() []TankLevel {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	list := make([]TankLevel, 0, len(w.tanks))
+	for _, t := range w.tanks {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Location < list[j].Location
+	})
+	return list
+}
+
+// ServeHTTP responds with the last level of all tanks as JSON.
+func (w *TankWatcher) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(w.List()); err != nil {
+		log.Println(err)
+	}
+}
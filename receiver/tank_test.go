@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTank_Level(t *testing.T) {
+	box := &Tank{Shape: TankBox, Length: 100, Width: 100, Height: 200, Offset: 20}
+	fill, volume := box.Level(120)
+	assert.Equal(t, 50.0, fill)
+	assert.Equal(t, 1000.0, volume)
+
+	fill, _ = box.Level(10)
+	assert.Equal(t, 100.0, fill, "water can't be above the maximum level")
+	fill, volume = box.Level(300)
+	assert.Equal(t, 0.0, fill)
+	assert.Equal(t, 0.0, volume)
+
+	table := &Tank{Shape: TankTable, Table: []TablePoint{{200, 0}, {20, 4000}, {100, 2000}}}
+	fill, volume = table.Level(60)
+	assert.Equal(t, 75.0, fill)
+	assert.Equal(t, 3000.0, volume)
+
+	assert.Error(t, (&Tank{Shape: TankCylinder, Height: 100}).validate())
+}
+
+func TestTankWatcher_thresholds(t *testing.T) {
+	low := 20.0
+	l, err := NewLocations("", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Configure("200", SensorConfig{
+		Location: "Grube",
+		Tank:     &Tank{Shape: TankBox, Length: 100, Width: 100, Height: 100, Low: &low},
+	}))
+
+	bus := NewBus()
+	alerts := bus.Subscribe("test", 10)
+	w := NewTankWatcher(bus, l)
+	report := func(fill float64) {
+		r := &Reading{Key: "200", Location: "Grube"}
+		r.Set(FieldFill, fill, UnitPercent)
+		w.Handle(Event{Kind: ReadingEvent, Reading: r})
+	}
+
+	report(30)
+	report(19)
+	report(18)
+	assert.Len(t, alerts, 1)
+	assert.False(t, (<-alerts).Alert.Resolved)
+
+	report(21)
+	assert.Len(t, alerts, 0, "within hysteresis")
+	report(25)
+	assert.Len(t, alerts, 1)
+	assert.True(t, (<-alerts).Alert.Resolved)
+	assert.Len(t, w.List(), 1)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultVoteWindow is the maximum gap between two frames of the same burst
+// for protocols that don't declare their own.
+const DefaultVoteWindow = time.Second
+
+// Vote configures a protocol to only accept a frame once several of the
+// repeated frames of a burst agree, which rejects frames with flipped bits.
+type Vote struct {
+	Frames   int           `yaml:"frames"`   // number of frames that must agree
+	Majority bool          `yaml:"majority"` // take a per-bit majority of the first frames instead of waiting for identical ones
+	Window   time.Duration `yaml:"window"`   // maximum gap between frames of a burst, 0 means DefaultVoteWindow
+}
+
+func (v *Vote) validate() error {
+	if v.Frames < 1 {
+		return fmt.Errorf("vote needs at least one frame")
+	}
+	if v.Window < 0 {
+		return fmt.Errorf("vote window must not be negative")
+	}
+	return nil
+}
+
+func (v *Vote) window() time.Duration {
+	if v.Window > 0 {
+		return v.Window
+	}
+	return DefaultVoteWindow
+}
+
+// burst is a series of frames of one sensor received in quick succession.
+type burst struct {
+	protocol string
+	window   time.Duration
+	last     time.Time
+	frames   []string
+	decided  bool
+}
+
+// Voter collects the frames of bursts and decides on their content.
+// It is safe for concurrent use.
+type Voter struct {
+	mu     sync.Mutex
+	bursts map[string]*burst
+}
+
+// NewVoter creates a Voter without any bursts.
+func NewVoter() *Voter {
+	return &Voter{
+		bursts: map[string]*burst{},
+	}
+}
+
+// Add adds a frame of the named protocol received by the receiver at t. Once
+// the frames of the burst agree, it returns the agreed binary representation
+// and true; all other frames of the burst, before and after the decision,
+// return false. Every receiver votes on the frames it received on its own,
+// and the frames of different sensors, as told apart by sensor, e.g. the
+// bits of their id, are voted on separately.
+func (v *Voter) Add(receiver, protocol, sensor, binSeq string, vote *Vote, t time.Time) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.expire(t)
+	key := receiver + "\x00" + protocol + "\x00" + sensor
+	b, ok := v.bursts[key]
+	if !ok {
+		b = &burst{protocol: protocol, window: vote.window()}
+		v.bursts[key] = b
+	}
+	b.last = t
+	if b.decided {
+		return "", false
+	}
+	b.frames = append(b.frames, binSeq)
+
+	var result string
+	if vote.Majority {
+		result, ok = majority(b.frames, vote.Frames)
+	} else {
+		result, ok = agreement(b.frames, vote.Frames)
+	}
+	b.decided = ok
+	return result, ok
+}
+
+// expire removes the bursts whose window passed before t
+// and counts those that weren't decided as rejected.
+func (v *Voter) expire(t time.Time) {
+	for key, b := range v.bursts {
+		if t.Sub(b.last) <= b.window {
+			continue
+		}
+		if !b.decided {
+			burstsRejected.With(prometheus.Labels{
+				ProtocolName: b.protocol,
+			}).Inc()
+		}
+		delete(v.bursts, key)
+	}
+}
+
+// agreement returns the frame that occurs at least n times.
+func agreement(frames []string, n int) (string, bool) {
+	counts := make(map[string]int, len(frames))
+	for _, f := range frames {
+		counts[f]++
+		if counts[f] >= n {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// majority returns the per-bit majority of the first n frames that have the
+// length of the first frame. Ties are decided by the first frame.
+func majority(frames []string, n int) (string, bool) {
+	var same []string
+	for _, f := range frames {
+		if len(f) == len(frames[0]) {
+			same = append(same, f)
+		}
+	}
+	if len(same) < n {
+		return "", false
+	}
+	same = same[:n]
+
+	result := []byte(same[0])
+	for i := range result {
+		ones := 0
+		for _, f := range same {
+			if f[i] == '1' {
+				ones++
+			}
+		}
+		switch {
+		case 2*ones > n:
+			result[i] = '1'
+		case 2*ones < n:
+			result[i] = '0'
+		}
+	}
+	return string(result), true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoter_agreement(t *testing.T) {
+	v := NewVoter()
+	vote := &Vote{Frames: 2}
+	start := time.Now()
+
+	_, ok := v.Add("", "p", "", "1010", vote, start)
+	assert.False(t, ok)
+	_, ok = v.Add("", "p", "", "1110", vote, start.Add(100*time.Millisecond))
+	assert.False(t, ok, "flipped bit")
+	bits, ok := v.Add("", "p", "", "1010", vote, start.Add(200*time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, "1010", bits)
+	_, ok = v.Add("", "p", "", "1010", vote, start.Add(300*time.Millisecond))
+	assert.False(t, ok, "burst already decided")
+
+	_, ok = v.Add("", "p", "", "1010", vote, start.Add(5*time.Second))
+	assert.False(t, ok, "new burst")
+}
+
+func TestVoter_majority(t *testing.T) {
+	v := NewVoter()
+	vote := &Vote{Frames: 3, Majority: true}
+	start := time.Now()
+
+	v.Add("", "p", "", "1010", vote, start)
+	v.Add("", "p", "", "10", vote, start)
+	v.Add("", "p", "", "0010", vote, start)
+	bits, ok := v.Add("", "p", "", "1011", vote, start)
+	assert.True(t, ok)
+	assert.Equal(t, "1010", bits)
+}
+
+func TestVoter_receivers(t *testing.T) {
+	v := NewVoter()
+	vote := &Vote{Frames: 2}
+	start := time.Now()
+
+	_, ok := v.Add("attic", "p", "", "1010", vote, start)
+	assert.False(t, ok)
+	_, ok = v.Add("cellar", "p", "", "1010", vote, start)
+	assert.False(t, ok, "frames of other receivers don't count")
+	_, ok = v.Add("cellar", "p", "", "1010", vote, start)
+	assert.True(t, ok)
+	_, ok = v.Add("attic", "p", "", "1010", vote, start)
+	assert.True(t, ok)
+}
+
+func TestVoter_sensors(t *testing.T) {
+	p := &Protocol{Fields: []Field{{Name: FieldID, Offset: 0, Width: 2}, {Name: FieldTemperature, Offset: 2}}}
+	v := NewVoter()
+	vote := &Vote{Frames: 2}
+	start := time.Now()
+
+	var decided []string
+	for i, frame := range []string{"011010", "100001", "011010", "100001"} {
+		if bits, ok := v.Add("", "p", p.identityBits(frame), frame, vote, start.Add(time.Duration(i)*100*time.Millisecond)); ok {
+			decided = append(decided, bits)
+		}
+	}
+	assert.Equal(t, []string{"011010", "100001"}, decided, "interleaved bursts of two sensors")
+}